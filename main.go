@@ -3,53 +3,229 @@
 // FITUR: Live benchmark, TUI 60 FPS, zero-jank, memory profiling, HTML graph
 // Compile: go build -o ceartax main.go -ldflags="-s -w"
 // Usage: ./ceartax -url target.com -ua-file ua.txt
+//        ./ceartax -url target.com -ua-file ua.txt -headless -output - | jq .
 
 package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"html/template"
 	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"math"
+	"math/bits"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	texttemplate "text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/andybalholm/brotli"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/http2"
 	"golang.org/x/net/proxy"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
 )
 
 // === BENCHMARK STRUCT ===
 type Benchmark struct {
-	Module     string        `json:"module"`
-	Start      time.Time     `json:"start"`
-	End        time.Time     `json:"end"`
-	Duration   time.Duration `json:"duration_ms"`
-	Requests   int           `json:"requests"`
-	RPS        float64       `json:"rps"`
-	MemoryPre  uint64        `json:"mem_pre_kb"`
-	MemoryPost uint64        `json:"mem_post_kb"`
-	DeltaKB    int64         `json:"mem_delta_kb"`
-	Status     string        `json:"status"`
+	Module   string        `json:"module"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration_ms"`
+	// Active and Wait split Duration by the share of worker time spent
+	// on the wire versus sleeping in politeness delays.
+	ActiveDuration time.Duration `json:"active_ms"`
+	WaitDuration   time.Duration `json:"wait_ms"`
+	Requests       int           `json:"requests"`
+	Errors         int           `json:"errors"`
+	Retries        int           `json:"retries"`
+	RPS            float64       `json:"rps"`
+	MemoryPre      uint64        `json:"mem_pre_kb"`
+	MemoryPost     uint64        `json:"mem_post_kb"`
+	DeltaKB        int64         `json:"mem_delta_kb"`
+	Status         string        `json:"status"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// === RESULT STRUCT ===
+type ReconResult struct {
+	ScanID          string                 `json:"scan_id"`
+	Target          string                 `json:"target"`
+	Timestamp       time.Time              `json:"timestamp"`
+	Status          string                 `json:"status"`          // complete, or interrupted: Ctrl+C cut the scan short and this is what it had
+	Subdomains      []string               `json:"subdomains"`      // only names that resolved; the rest are dropped
+	LiveSubdomains  []string               `json:"live_subdomains"` // not catch-all, not excluded code
+	SubdomainIPs    map[string][]string    `json:"subdomain_ips"`   // A and AAAA per subdomain, IPv4 first
+	OpenPorts       []int                  `json:"open_ports"`
+	HostPorts       map[string][]int       `json:"host_ports,omitempty"`        // CIDR target: open ports per address
+	ConnectMs       map[int]float64        `json:"connect_ms"`                  // open port -> TCP handshake time
+	ClosedMs        map[int]float64        `json:"closed_connect_ms,omitempty"` // refused port -> RST time, with -connect-timing-closed
+	Banners         map[int]string         `json:"banners,omitempty"`           // open port -> first line the service sent
+	Directories     []string               `json:"directories"`                 // probed paths whose status matched -match-codes
+	DirStatuses     map[string]int         `json:"dir_statuses"`                // every probed path -> status, matched or not
+	TechStack       map[string]string      `json:"tech_stack"`
+	Headers         map[string][]string    `json:"headers"`                    // every value kept; Set-Cookie values may contain commas
+	RedirectChain   []string               `json:"redirect_chain,omitempty"`   // URLs Fingerprint went through, first to last, at most 10 redirects
+	FinalURL        string                 `json:"final_url,omitempty"`        // where GET / landed; Headers are from this response
+	ContentEncoding string                 `json:"content_encoding,omitempty"` // of that response, decoded before any body is read
+	HTTPVersions    map[string]bool        `json:"http_versions,omitempty"`    // "HTTP/2", "HTTP/3" -> supported; HTTP/3 only as advertised in Alt-Svc
+	TLSInfo         map[string]string      `json:"tls_info"`
+	Streams         map[string]StreamStats `json:"streams,omitempty"` // per streaming sink
+	MethodProbes    []MethodProbe          `json:"method_probes,omitempty"`
+	LoadBalancer    *LBVerdict             `json:"load_balancer,omitempty"`
+	PageIssues      []PageIssue            `json:"page_issues,omitempty"`
+	SuppressionLog  *SuppressionLog        `json:"suppression_log,omitempty"`
+	HeaderAnomalies []string               `json:"header_anomalies,omitempty"` // protocol oddities in the raw reply to /
+	APISpecs        []APISpec              `json:"api_specs,omitempty"`
+	Endpoints       []Endpoint             `json:"endpoints,omitempty"`       // from parsed API specs
+	DNSRecords      map[string][]string    `json:"dns_records,omitempty"`     // MX, TXT, NS, CNAME, SOA; SPF and DMARC split out of TXT
+	RobotsDisallow  []string               `json:"robots_disallow,omitempty"` // Disallow rules for *, with -respect-robots
+	RobotsSkipped   []string               `json:"robots_skipped,omitempty"`  // candidate paths Dirs left out because of them
+	Matches         []Match                `json:"matches,omitempty"`         // -grep hits in fetched bodies
+	Screenshots     []Screenshot           `json:"screenshots,omitempty"`
+
+	TotalRequests int64 `json:"total_requests"`
+	TotalBytes    int64 `json:"total_bytes"`
+
+	Aborted map[string]string `json:"aborted,omitempty"` // module -> reason
+	Failed  map[string]string `json:"failed,omitempty"`  // module -> error
+
+	Unreachable map[string]string `json:"unreachable,omitempty"` // host -> why it was given up on
+
+	Findings []Finding `json:"findings"` // every emitted finding with its source module
+}
+
+// SuppressionLog explains every host the scan filtered out, with the
+// evidence used, so a real host dropped by mistake can be spotted.
+type SuppressionLog struct {
+	CatchAll *CatchAll    `json:"catch_all,omitempty"`
+	Hosts    []Suppressed `json:"hosts,omitempty"`
+}
+
+// CatchAll is the baseline page found for a vhost that cannot exist.
+type CatchAll struct {
+	Probe  string   `json:"probe"`         // the bogus host name asked for
+	Method string   `json:"method"`        // wildcard-dns or host-header
+	IPs    []string `json:"ips,omitempty"` // wildcard DNS answers
+	Hash   string   `json:"hash"`          // sha256 of the baseline body
+}
+
+type Suppressed struct {
+	Host     string `json:"host"`
+	Reason   string `json:"reason"` // excluded, status, catch-all
+	Evidence string `json:"evidence"`
+}
+
+// Finding is a single discovery, pushed to streaming sinks as it happens.
+type Finding struct {
+	ScanID string    `json:"scan_id"`
+	Type   string    `json:"type"`   // subdomain, port, dir, tech, waf, ...
+	Source string    `json:"source"` // module that found it
+	Target string    `json:"target"`
+	Value  string    `json:"value"`
+	Time   time.Time `json:"time"`
+	// Interest is info, low, medium or high, from the first matching
+	// interestRules entry.
+	Interest string `json:"interest"`
+}
+
+// Rule scores findings: one whose Type matches (empty matches any) and
+// whose Value matches Match (nil matches any) gets Interest.
+type Rule struct {
+	Type     string
+	Match    *regexp.Regexp
+	Interest string
+}
+
+// interestRules are tried in order and the first hit wins; a finding no
+// rule matches is info. Add a line to flag another kind of finding.
+var interestRules = []Rule{
+	// VCS metadata, credentials and admin panels.
+	{"dir", regexp.MustCompile(`/\.(git|svn|hg)(/|$)`), "high"},
+	{"dir", regexp.MustCompile(`(?i)/\.(env|htpasswd|aws|ssh)(/|$)`), "high"},
+	{"dir", regexp.MustCompile(`(?i)/(admin|administrator|wp-admin|wp-login\.php|phpmyadmin|cpanel|manager/html|console|dashboard)(/|$)`), "high"},
+	// Backups and dumps.
+	{"dir", regexp.MustCompile(`(?i)(\.(bak|old|orig|sql|swp|zip|tar\.gz)|~)$`), "medium"},
+	// Stack traces in -grep hits (Java, Python, PHP).
+	{"match", regexp.MustCompile(`(?i)stack ?trace|traceback \(most recent call last\)|exception in thread|\bat [\w.$]+\(\w+\.java:\d+\)|fatal error:.* on line \d+`), "high"},
+	{"api-spec", nil, "medium"},
+	{"header-anomaly", nil, "medium"},
+	// Databases, remote admin and cleartext logins; any other port is low.
+	{"port", regexp.MustCompile(`(^|:)(21|23|445|1433|3306|3389|5432|6379|9200|11211|27017)$`), "medium"},
+	{"port", nil, "low"},
+	// A versioned product can be looked up for known CVEs.
+	{"tech", regexp.MustCompile(` [\d.]+$`), "low"},
+}
+
+// interestRank orders interest levels for sorting, highest first.
+var interestRank = map[string]int{"high": 3, "medium": 2, "low": 1, "info": 0}
+
+// scoreFinding is the scoring pass: emit runs every finding through it
+// before recording or streaming it.
+func scoreFinding(f Finding) string {
+	for _, r := range interestRules {
+		if (r.Type == "" || r.Type == f.Type) && (r.Match == nil || r.Match.MatchString(f.Value)) {
+			return r.Interest
+		}
+	}
+	return "info"
+}
+
+// Match is one -grep hit in a response body.
+type Match struct {
+	URL  string `json:"url"`
+	Text string `json:"match"`
+}
+
+type StreamStats struct {
+	Delivered int64 `json:"delivered"`
+	Dropped   int64 `json:"dropped"`
 }
 
 // === STYLING (PRE-CACHED) ===
@@ -60,366 +236,5307 @@ var (
 	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00"))
 	barStyle     = lipgloss.NewStyle().Width(30)
 	fpsStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF"))
+	logStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
 )
 
 // === TUI MESSAGES ===
 type frameMsg struct{}
-type progressMsg struct{ module string; value float64 }
+type progressMsg struct {
+	module string
+	value  float64 // 0..1, or negative when the total is unknown
+	done   int     // with a negative value: items finished so far
+}
 type benchMsg struct{ b Benchmark }
 type doneMsg struct{}
+type interruptTimeoutMsg struct{}
+type signalMsg struct{} // SIGINT or SIGTERM from outside the terminal
+type logMsg string
 
 // === TUI MODEL ===
 type model struct {
-	ceartax      *Ceartax
-	progress     map[string]progress.Model
-	spinner      spinner.Model
-	width        int
-	phase        string
-	benchmarks   []Benchmark
-	startTime    time.Time
-	frameCount   int
-	lastFrame    time.Time
-	fps          float64
-	repaintCh    chan struct{}
-	ready        bool
+	ceartax    *Ceartax
+	progress   map[string]progress.Model
+	counts     map[string]int // modules with no known total, shown as a spinner and count
+	rates      map[string]*progressRate
+	spinner    spinner.Model
+	width      int
+	phase      string
+	benchmarks []Benchmark
+	startTime  time.Time
+	frameCount int
+	lastFrame  time.Time
+	fps        float64
+	repaintCh  chan struct{}
+	ready      bool
+	paused     bool
+	logs       []string // last logKeep lines of the activity feed
+	logScroll  int      // lines scrolled back from the newest
+	peakKB     uint64   // highest heap sample, see sampleMem
+	memAt      time.Time
+	stay       bool     // keep the results screen up for re-runs instead of quitting
+	saveErrs   []string // sinks that failed to write, shown on the results screen
+}
+
+// rerunKeys are the results-screen keys that re-run one module, with the
+// progress bar that module drives.
+var rerunKeys = []struct{ key, module, bar string }{
+	{"s", "Subdomains", "sub"}, {"c", "CrtSh", "crt"}, {"n", "DNS", "dns"}, {"p", "Ports", "ports"},
+	{"f", "Fingerprint", "fp"}, {"i", "Favicon", "fav"}, {"w", "WAF", "waf"}, {"t", "TLS", "tls"},
+	{"d", "Directories", "dirs"}, {"a", "APISpecs", "api"}, {"x", "Screenshots", "shot"},
 }
 
+const (
+	logKeep  = 200
+	logLines = 8
+)
+
+// progressRate tracks how fast one module's bar moves, for its ETA.
+// Modules share workers and the rate limit, so an overall average from
+// the module's start would lag; the rate is smoothed from the change
+// between consecutive progress updates instead.
+type progressRate struct {
+	value   float64
+	at      time.Time
+	perSec  float64 // of the whole bar, exponentially smoothed
+	samples int
+}
+
+const (
+	etaMinSamples = 3   // updates with movement before an ETA is shown
+	etaSmoothing  = 0.3 // weight of the newest rate sample
+)
+
+func (r *progressRate) update(value float64, now time.Time) {
+	if dt := now.Sub(r.at).Seconds(); !r.at.IsZero() && dt > 0 && value > r.value {
+		rate := (value - r.value) / dt
+		if r.samples == 0 {
+			r.perSec = rate
+		} else {
+			r.perSec = etaSmoothing*rate + (1-etaSmoothing)*r.perSec
+		}
+		r.samples++
+	}
+	r.value, r.at = value, now
+}
+
+// eta is the estimated time left as "~12s", or "--" until there are
+// enough samples to go on.
+func (r *progressRate) eta() string {
+	if r.samples < etaMinSamples || r.perSec <= 0 {
+		return "--"
+	}
+	return "~" + time.Duration((1-r.value)/r.perSec*float64(time.Second)).Round(time.Second).String()
+}
+
+// interruptGrace is how long Ctrl+C waits for the cancelled modules to
+// report back before saving whatever the result holds.
+const interruptGrace = 5 * time.Second
+
 func initialModel(c *Ceartax) model {
 	return model{
-		ceartax:    c,
-		progress:   make(map[string]progress.Model),
-		spinner:    spinner.New(spinner.WithSpinner(spinner.MiniDot)),
-		phase:      "Initializing...",
-		startTime:  time.Now(),
-		lastFrame:  time.Now(),
-		repaintCh:  make(chan struct{}, 1),
+		ceartax:   c,
+		progress:  make(map[string]progress.Model),
+		counts:    make(map[string]int),
+		rates:     make(map[string]*progressRate),
+		spinner:   spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		phase:     "Initializing...",
+		startTime: time.Now(),
+		lastFrame: time.Now(),
+		repaintCh: make(chan struct{}, 1),
 	}
 }
 
+// === CONFIG ===
+// Config holds everything main parses from the command line.
+type Config struct {
+	Target    string
+	ProxyURL  string
+	ProxyFile string // one proxy URL per line, rotated per request
+	UAFile    string
+
+	SubWordlist  string // one label per line, empty uses the built-in list
+	RecurseDepth int    // wordlist rounds; 2 also brutes under every host round 1 found
+	Timeout      time.Duration
+
+	// Outputs. Relative paths land under OutputDir/PerTargetDir/<target>/,
+	// each part only if set.
+	Output        string        // JSON path; HTML and text are derived from it. Empty disables all three.
+	Formats       []string      // which of json, html, txt, csv, nmap-xml to write at Output
+	SplitDir      string        // one plain-text file per category, empty disables
+	StreamURL     string        // NDJSON collector that receives findings live
+	StreamFile    string        // NDJSON file, appended one finding per line as found
+	ESURL         string        // Elasticsearch base URL for _bulk indexing
+	ESFile        string        // _bulk NDJSON file instead of (or besides) ESURL
+	ESIndex       string        // index name, {date} expands to YYYY.MM.DD
+	BatchSize     int           // findings per streamed batch, 0 = default
+	FlushInterval time.Duration // max age of a streamed batch, 0 = default
+	AssetsOut     string        // normalized asset inventory for ASM import
+	SARIFOut      string        // SARIF 2.1.0 log for code-scanning dashboards
+	BenchOut      string        // benchmarks plus totals as JSON, for CI comparisons
+	WebhookURL    string        // POST a summary here once the reports are written
+	WebhookFormat string        // json (default) or slack
+	CertDir       string        // PEM chains, one file per host
+	ChromePath    string        // browser for screenshots, empty = no Screenshots module
+	OutputDir     string
+	PerTargetDir  string
+	MultiTarget   bool // without PerTargetDir, suffix output names with the target
+	Timestamp     bool // suffix output names with the scan start time
+	RawBytes      bool // base64 wire values instead of escaping them
+
+	// HTTP behaviour. Every body read gets the per-page budget.
+	PageTimeout    time.Duration
+	PageMaxBytes   int64
+	AcceptLanguage string         // picks the localized variant; merged into Headers unless they set it
+	Headers        http.Header    // -header and -cookie, sent to the target and its subdomains only
+	MethodFuzz     bool           // compare GET with uncommon methods on /
+	RespectRobots  bool           // skip directory candidates robots.txt disallows
+	Grep           *regexp.Regexp // searched for in Fingerprint and Dirs bodies, nil = off
+	TagHeader      string         // per-request ID header for log correlation, empty = off
+	LBSamples      int            // identical requests for LB detection, 0 = off
+	BasePath       string         // prefix for target probes, e.g. an app mounted at /api/v2
+
+	// Scan behaviour.
+	Modules          []string      // lowercase module names to run, empty runs all
+	BenchOnly        bool          // no delays, no sinks; benchmarks only
+	DryRun           bool          // no sinks; main prints Plan and sends nothing
+	ListOnly         bool          // discovery only; host list is the output
+	InterModuleDelay time.Duration // gap between module starts
+	ModuleTimeout    time.Duration // wall-clock limit per module, 0 = none
+	AbortOnFindings  int           // stop a module after this many findings, 0 = never
+	MaxFailures      int           // give up on a host after this many connection failures in a row, 0 = never
+	MaxRetries       int           // extra attempts for transient HTTP failures (Fingerprint, Dirs)
+	AliveExclude     []int         // status codes that do not make a subdomain live
+	MatchCodes       []int         // statuses that make a probed path a directory finding, empty = below 400
+	Extensions       []string      // also try every directory candidate with these suffixes
+	DirRecurse       bool          // probe the candidates again under every directory found
+	DirRecurseDepth  int           // directory levels with DirRecurse, 0 = 2
+	DNSServers       []string      // resolvers to round-robin, empty uses the system one
+	DNSTimeout       time.Duration // per query, 0 leaves it to the resolver
+
+	// Known or out-of-scope assets, skipped before probing. Hosts may be
+	// bare labels ("api") or full names.
+	ExcludeHosts []string
+	ExcludePorts []int
+
+	Ports      []int    // ports to scan, empty = 80, 443, 22
+	RangeHosts []string // addresses of a CIDR target; Ports scans each one
+
+	Network string // "tcp4"/"tcp6" pins the address family, default "tcp"
+
+	// Port scanner tuning.
+	DialTimeout time.Duration
+	ReadBuffer  int  // banner read size and SO_RCVBUF of the socket it is read from, 0 = 1KB and the OS default
+	TimeClosed  bool // also record connect time of refused ports
+	PortBatch   int  // ports dialed concurrently per batch
+
+	Concurrency int     // workers per module and global in-flight cap, clamped to [1, maxConcurrency]
+	RPS         float64 // requests per second across all modules; 0 keeps the random per-probe delay
+
+	LogLevel slog.Level // -v info, -vv debug (every request), default warn
+	LogOut   io.Writer  // nil feeds the TUI log panel
+}
+
+const (
+	defaultConcurrency = 10
+	maxConcurrency     = 500
+)
+
+// newScanID returns a random (version 4) UUID.
+func newScanID() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
 // === CEARTAX CORE ===
 type Ceartax struct {
+	scanID   string // correlates every artifact of one run
 	target   string
 	proxyURL string
+	proxies  []string
 	timeout  time.Duration
 	output   string
+	formats  map[string]bool
+	splitDir string
 	uaList   []string
-	client   *http.Client
-	result   ReconResult
-	mu       sync.Mutex
-	chProg   chan progressMsg
-	chBench  chan benchMsg
-	chDone   chan doneMsg
-	pool     *errgroup.Group
-	ctx      context.Context
-	cancel   context.CancelFunc
+
+	subWordlist  []string
+	subWordFile  string // set instead of subWordlist for a large -sub-wordlist
+	subWordCount int
+	recurseDepth int
+
+	pageTimeout time.Duration
+	pageMax     int64
+
+	outputDir    string
+	perTargetDir string
+	multiTarget  bool
+	stamp        string // -timestamp suffix, empty without it
+	certDir      string
+	chromePath   string
+	hostsReady   sync.WaitGroup // modules Screenshots waits for, see hostSources
+	certChains   map[string][]*x509.Certificate
+	sinks        []Sink
+	dns          *resolverPool
+
+	modules   map[string]bool // empty runs every module
+	benchOnly bool
+	listOnly  bool
+	rawBytes  bool
+	scheduled int
+
+	headers     http.Header
+	extensions  []string // -extensions, without the dot
+	dirRecurse  bool
+	dirDepth    int // levels with -recurse, 1 = the top level only
+	tagHeader   string
+	tagSeq      atomic.Int64
+	basePath    string // always "/" or "/prefix/"
+	moduleDelay time.Duration
+	moduleLimit time.Duration
+	methodFuzz  bool
+
+	respectRobots bool
+	robotsMu      sync.Mutex // held across the fetch so it happens once
+	robotsDone    bool
+	robots        []robotsRule
+	robotsErr     error
+	lbSamples     int
+	grep          *regexp.Regexp
+
+	abortAfter   int
+	maxFails     int
+	maxRetries   int
+	hostFails    sync.Map // host -> *atomic.Int64, consecutive failures
+	aliveExclude map[int]bool
+	matchCodes   map[int]bool // nil: every status below 400 matches
+	excludeHosts map[string]bool
+	excludePorts map[int]bool
+	ports        []int
+	rangeHosts   []string
+
+	totalRequests atomic.Int64
+	totalBytes    atomic.Int64
+
+	network     string // tcp, tcp4 or tcp6
+	dialTimeout time.Duration
+	readBuffer  int
+	timeClosed  bool
+	portBatch   int
+	concurrency int
+	limiter     *rate.Limiter // nil without -rps
+	sem         chan struct{}
+
+	client  *http.Client
+	result  ReconResult
+	mu      sync.Mutex
+	chProg  chan progressMsg
+	chLog   chan logMsg
+	log     *slog.Logger
+	chBench chan benchMsg
+	chDone  chan doneMsg
+	pool    *errgroup.Group
+	runErr  error
+
+	interrupted atomic.Bool // Ctrl+C: stop the sweep, not just this target
+	pauseMu     sync.Mutex
+	resume      chan struct{} // non-nil while paused, closed to resume
+	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
-func NewCeartax(target, proxyURL, uaFile, output string, timeout time.Duration) *Ceartax {
+func NewCeartax(cfg Config) (*Ceartax, error) {
 	ctx, cancel := context.WithCancel(context.Background())
+	scanID := newScanID()
 	c := &Ceartax{
-		target:   target,
-		proxyURL: proxyURL,
-		timeout:  timeout,
-		output:   output,
+		scanID:   scanID,
+		target:   cfg.Target,
+		proxyURL: cfg.ProxyURL,
+		timeout:  cfg.Timeout,
+		output:   cfg.Output,
+		formats:  make(map[string]bool),
+		splitDir: cfg.SplitDir,
+
+		pageTimeout: cfg.PageTimeout,
+		pageMax:     cfg.PageMaxBytes,
+
+		recurseDepth: max(cfg.RecurseDepth, 1),
+
+		outputDir:    cfg.OutputDir,
+		perTargetDir: cfg.PerTargetDir,
+		multiTarget:  cfg.MultiTarget,
+		certDir:      cfg.CertDir,
+		chromePath:   cfg.ChromePath,
+		certChains:   make(map[string][]*x509.Certificate),
+
+		benchOnly: cfg.BenchOnly,
+		listOnly:  cfg.ListOnly,
+		rawBytes:  cfg.RawBytes,
+		dns:       newResolverPool(cfg.DNSServers, cfg.DNSTimeout),
+
+		headers:     localeHeaders(cfg.Headers, cfg.AcceptLanguage),
+		extensions:  cfg.Extensions,
+		dirRecurse:  cfg.DirRecurse,
+		dirDepth:    cfg.DirRecurseDepth,
+		tagHeader:   cfg.TagHeader,
+		basePath:    normBasePath(cfg.BasePath),
+		moduleDelay: cfg.InterModuleDelay,
+		moduleLimit: cfg.ModuleTimeout,
+		methodFuzz:  cfg.MethodFuzz,
+
+		respectRobots: cfg.RespectRobots,
+		lbSamples:     cfg.LBSamples,
+		grep:          cfg.Grep,
+
+		abortAfter:   cfg.AbortOnFindings,
+		maxFails:     cfg.MaxFailures,
+		maxRetries:   max(cfg.MaxRetries, 0),
+		aliveExclude: make(map[int]bool),
+		excludeHosts: make(map[string]bool),
+		excludePorts: make(map[int]bool),
+		ports:        cfg.Ports,
+		rangeHosts:   cfg.RangeHosts,
+
+		network:     cfg.Network,
+		dialTimeout: cfg.DialTimeout,
+		readBuffer:  cfg.ReadBuffer,
+		timeClosed:  cfg.TimeClosed,
+		portBatch:   max(cfg.PortBatch, 1),
+		concurrency: min(max(cfg.Concurrency, 1), maxConcurrency),
+
 		result: ReconResult{
-			Target:    target,
-			TechStack: make(map[string]string),
-			Headers:   make(map[string]string),
-			TLSInfo:   make(map[string]string),
-			Timestamp: time.Now(),
+			ScanID:       scanID,
+			Target:       cfg.Target,
+			TechStack:    make(map[string]string),
+			Headers:      make(map[string][]string),
+			ConnectMs:    make(map[int]float64),
+			Banners:      make(map[int]string),
+			SubdomainIPs: make(map[string][]string),
+			DirStatuses:  make(map[string]int),
+			TLSInfo:      make(map[string]string),
+			Aborted:      make(map[string]string),
+			Failed:       make(map[string]string),
+			Timestamp:    time.Now(),
 		},
 		chProg:  make(chan progressMsg, 50),
+		chLog:   make(chan logMsg, 256),
 		chBench: make(chan benchMsg, 10),
 		chDone:  make(chan doneMsg, 1),
 		pool:    &errgroup.Group{},
 		ctx:     ctx,
 		cancel:  cancel,
 	}
-	c.loadUAs(uaFile)
-	c.initClient()
-	return c
-}
-
-func (c *Ceartax) loadUAs(file string) {
-	f, _ := os.Open(file)
-	sc := bufio.NewScanner(f)
-	for sc.Scan() {
-		if ua := strings.TrimSpace(sc.Text()); ua != "" && !strings.HasPrefix(ua, "#") {
-			c.uaList = append(c.uaList, ua)
+	if cfg.Timestamp {
+		c.stamp = c.result.Timestamp.Format("20060102-150405")
+	}
+	if c.dirDepth <= 0 {
+		c.dirDepth = 2
+	}
+	c.log = c.newLogger(cfg.LogOut, cfg.LogLevel)
+	for _, code := range cfg.AliveExclude {
+		c.aliveExclude[code] = true
+	}
+	if len(cfg.MatchCodes) > 0 {
+		c.matchCodes = make(map[int]bool)
+		for _, code := range cfg.MatchCodes {
+			c.matchCodes[code] = true
+		}
+	}
+	for _, h := range cfg.ExcludeHosts {
+		h = strings.ToLower(strings.TrimSuffix(h, "."))
+		if !strings.Contains(h, ".") {
+			h += "." + c.target
+		}
+		c.excludeHosts[h] = true
+	}
+	for _, f := range cfg.Formats {
+		c.formats[f] = true
+	}
+	if len(cfg.Modules) > 0 {
+		c.modules = make(map[string]bool)
+		for _, m := range cfg.Modules {
+			c.modules[strings.ToLower(m)] = true
 		}
 	}
-	f.Close()
-	if len(c.uaList) == 0 {
+	for _, p := range cfg.ExcludePorts {
+		c.excludePorts[p] = true
+	}
+	if c.pageMax <= 0 {
+		c.pageMax = defaultPageMax
+	}
+	if c.network == "" {
+		c.network = "tcp"
+	}
+	c.sem = make(chan struct{}, c.concurrency)
+	if cfg.RPS > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(cfg.RPS), 1)
+	}
+	if err := c.loadUAs(cfg.UAFile); err != nil {
+		return nil, err
+	}
+	if err := c.loadProxies(cfg.ProxyFile); err != nil {
+		return nil, err
+	}
+	if err := c.loadSubWords(cfg.SubWordlist); err != nil {
+		return nil, err
+	}
+	if err := c.initClient(); err != nil {
+		return nil, err
+	}
+	c.sinks = c.buildSinks(cfg)
+	return c, nil
+}
+
+// loadUAs reads -ua-file. Only "no file" (a -dry-run that skipped a
+// remote list) falls back to the built-in UA; a file that is missing,
+// unreadable or empty is an error.
+func (c *Ceartax) loadUAs(file string) error {
+	if file == "" {
 		c.uaList = []string{"Ceartax/2.3"}
+		return nil
+	}
+	list, err := readLines(file)
+	if err != nil {
+		return fmt.Errorf("-ua-file: %w", err)
 	}
+	if len(list) == 0 {
+		return fmt.Errorf("-ua-file: %s kosong", file)
+	}
+	c.uaList = list
+	return nil
 }
 
 func (c *Ceartax) randomUA() string {
 	return c.uaList[rand.Intn(len(c.uaList))]
 }
 
-func (c *Ceartax) initClient() {
-	tr := &http.Transport{
-		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
-		MaxIdleConns:      30,
-		IdleConnTimeout:   20 * time.Second,
-		DisableKeepAlives: false,
+// loadProxies reads -proxy-file. A -proxy given as well joins the pool.
+func (c *Ceartax) loadProxies(file string) error {
+	if file == "" {
+		return nil
+	}
+	list, err := readLines(file)
+	if err != nil {
+		return fmt.Errorf("-proxy-file: %w", err)
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("-proxy-file: %s kosong", file)
 	}
 	if c.proxyURL != "" {
-		dialer, _ := proxy.SOCKS5("tcp", strings.TrimPrefix(c.proxyURL, "socks5://"), nil, proxy.Direct)
-		tr.DialContext = dialer.DialContext
+		list = append([]string{c.proxyURL}, list...)
 	}
-	c.client = &http.Client{Transport: tr, Timeout: c.timeout}
+	c.proxies = list
+	return nil
 }
 
-func (c *Ceartax) randomDelay() {
-	select {
-	case <-c.ctx.Done():
-	case <-time.After(time.Duration(rand.Intn(2)+1) * time.Second):
+// loadSubWords reads -sub-wordlist with the same rules as loadUAs; no file
+// means the built-in list. Files of subStreamMin bytes or more are only
+// counted here and streamed from disk each round (see fileSource).
+func (c *Ceartax) loadSubWords(file string) error {
+	c.subWordlist = defaultSubWords
+	if file == "" {
+		return nil
 	}
-}
-
-func (c *Ceartax) runBench(name string, fn func()) {
-	c.pool.Go(func() error {
-		b := Benchmark{
-			Module:    name,
-			Start:     time.Now(),
-			MemoryPre: c.memKB(),
+	if fi, err := os.Stat(file); err == nil && fi.Size() >= subStreamMin {
+		n, err := countLines(file)
+		if err != nil {
+			return fmt.Errorf("-sub-wordlist: %w", err)
 		}
-		defer func() {
-			b.End = time.Now()
-			b.Duration = b.End.Sub(b.Start)
-			b.MemoryPost = c.memKB()
-			b.DeltaKB = int64(b.MemoryPost) - int64(b.MemoryPre)
-			b.Status = "DONE"
-			if b.Requests > 0 {
-				b.RPS = float64(b.Requests) / b.Duration.Seconds()
-			}
-			c.chBench <- benchMsg{b: b}
-		}()
-		fn()
+		if n == 0 {
+			return fmt.Errorf("-sub-wordlist: %s kosong", file)
+		}
+		c.subWordlist, c.subWordFile, c.subWordCount = nil, file, n
 		return nil
-	})
-}
-
-func (c *Ceartax) memKB() uint64 {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	return m.Alloc / 1024
+	}
+	words, err := readLines(file)
+	if err != nil {
+		return fmt.Errorf("-sub-wordlist: %w", err)
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("-sub-wordlist: %s kosong", file)
+	}
+	c.subWordlist = words
+	return nil
 }
 
-// === MODULES ===
-func (c *Ceartax) Subdomains() {
-	defer c.moduleDone()
-	words := [...]string{"www", "api", "admin", "mail", "dev"}
-	total := float64(len(words))
-	for i, w := range words {
-		c.randomDelay()
-		if _, err := net.LookupHost(w + "." + c.target); err == nil {
-			c.mu.Lock()
-			c.result.Subdomains = append(c.result.Subdomains, w+"."+c.target)
-			c.mu.Unlock()
+func (c *Ceartax) initClient() error {
+	tr := &http.Transport{
+		// Modules expect HTTP/1.1 replies (raw heads, per-request
+		// connections), so say so in ALPN; probeHTTP2 offers h2 itself.
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection:   c.verifyReport,
+			NextProtos:         []string{"http/1.1"},
+		},
+		MaxIdleConns:      30,
+		IdleConnTimeout:   20 * time.Second,
+		DisableKeepAlives: false,
+		// newRequest asks for gzip, deflate and br itself and do decodes
+		// them, so the transport must not touch either side.
+		DisableCompression: true,
+	}
+	if c.network != "tcp" {
+		d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		tr.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return d.DialContext(ctx, c.network, addr)
 		}
-		c.chProg <- progressMsg{module: "sub", value: float64(i+1) / total}
 	}
-}
-
-func (c *Ceartax) Ports() {
-	defer c.moduleDone()
-	ports := [...]int{80, 443, 22}
-	total := float64(len(ports))
-	for i, p := range ports {
-		c.randomDelay()
-		if conn, _ := net.DialTimeout("tcp", c.target+":"+fmt.Sprint(p), 1*time.Second); conn != nil {
-			c.mu.Lock()
-			c.result.OpenPorts = append(c.result.OpenPorts, p)
-			c.mu.Unlock()
-			conn.Close()
+	// Through a proxy the proxy picks the address family to the target.
+	if len(c.proxies) > 0 {
+		rot, err := newProxyRotator(tr, c.proxies)
+		if err != nil {
+			return fmt.Errorf("-proxy-file: %w", err)
 		}
-		c.chProg <- progressMsg{module: "ports", value: float64(i+1) / total}
+		rot.log = c.log
+		c.client = &http.Client{Transport: rot, Timeout: c.timeout, CheckRedirect: checkRedirect}
+		return nil
+	}
+	if err := useProxy(tr, c.proxyURL); err != nil {
+		return fmt.Errorf("-proxy: %w", err)
 	}
+	c.client = &http.Client{Transport: tr, Timeout: c.timeout, CheckRedirect: checkRedirect}
+	return nil
 }
 
-func (c *Ceartax) Fingerprint() {
-	defer c.moduleDone()
-	req, _ := http.NewRequestWithContext(c.ctx, "GET", "https://"+c.target, nil)
-	req.Header.Set("User-Agent", c.randomUA())
-	if resp, err := c.client.Do(req); err == nil {
-		defer resp.Body.Close()
-		for k, v := range resp.Header {
-			c.result.Headers[strings.ToLower(k)] = strings.Join(v, ", ")
+// useProxy routes tr through proxyURL: socks5:// replaces the dialer,
+// http:// and https:// go through tr.Proxy (CONNECT for TLS targets).
+func useProxy(tr *http.Transport, proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("proxy %q: %w", proxyURL, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy %q: missing host", proxyURL)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: pass}
+		}
+		dialer, err := proxy.SOCKS5("tcp", u.Host, auth, proxy.Direct)
+		if err != nil {
+			return fmt.Errorf("proxy %q: %w", proxyURL, err)
 		}
-		c.chProg <- progressMsg{module: "fp", value: 1.0}
+		tr.DialContext = dialer.(proxy.ContextDialer).DialContext
+	case "http", "https":
+		tr.Proxy = http.ProxyURL(u)
+	default:
+		return fmt.Errorf("proxy %q: unsupported scheme %q (want socks5, http or https)", proxyURL, u.Scheme)
 	}
+	return nil
 }
 
-func (c *Ceartax) Dirs() {
-	defer c.moduleDone()
-	dirs := [...]string{".git", "robots.txt", "admin"}
-	ch := make(chan string, len(dirs))
-	for _, d := range dirs { ch <- d }
-	close(ch)
-	var wg sync.WaitGroup
-	for i := 0; i < 2; i++ {
-		wg.Add(1)
-		go func() { defer wg.Done()
-			for d := range ch {
-				u := "https://" + c.target + "/" + d
-				req, _ := http.NewRequestWithContext(c.ctx, "HEAD", u, nil)
-				req.Header.Set("User-Agent", c.randomUA())
-				if resp, _ := c.client.Do(req); resp != nil && resp.StatusCode < 400 {
-					c.mu.Lock()
-					c.result.Directories = append(c.result.Directories, u)
-					c.mu.Unlock()
-				}
-			}
-		}()
-	}
-	wg.Wait()
-	c.chProg <- progressMsg{module: "dirs", value: 1.0}
-}
+const maxRedirects = 10
 
-func (c *Ceartax) moduleDone() { c.chDone <- doneMsg{} }
+// keepLastRedirect in a request context makes the redirect cap hand back
+// the last response instead of an error, for callers that record chains.
+type keepLastRedirect struct{}
 
-func (c *Ceartax) Run() {
-	c.runBench("Subdomains", c.Subdomains)
-	c.runBench("Ports", c.Ports)
-	c.runBench("Fingerprint", c.Fingerprint)
-	c.runBench("Directories", c.Dirs)
-	go func() {
-		c.pool.Wait()
-		c.chDone <- doneMsg{}
-	}()
+// checkRedirect caps redirects at maxRedirects, like the default policy.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) < maxRedirects {
+		return nil
+	}
+	if req.Context().Value(keepLastRedirect{}) != nil {
+		return http.ErrUseLastResponse
+	}
+	return fmt.Errorf("stopped after %d redirects", maxRedirects)
 }
 
-// === TUI ===
-func (m model) Init() tea.Cmd {
-	m.ceartax.Run()
-	return tea.Batch(
-		m.frameCmd(),
-		m.progressCmd(),
-		m.benchCmd(),
-	)
+// redirectChain lists the URLs that led to resp, first request first.
+// net/http links each followed request to the redirect that caused it.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req != nil; {
+		chain = append(chain, req.URL.String())
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	slices.Reverse(chain)
+	return chain
 }
 
-func (m model) frameCmd() tea.Cmd {
-	return tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
-		m.frameCount++
-		if time.Since(m.lastFrame) >= time.Second {
-			m.fps = float64(m.frameCount) / time.Since(m.lastFrame).Seconds()
-			m.frameCount = 0
-			m.lastFrame = time.Now()
-		}
-		select {
-		case m.repaintCh <- struct{}{}:
-		default:
-		}
-		return frameMsg{}
-	})
+const proxyAttempts = 3
+
+// proxyRotator sends each request through a random proxy from the pool,
+// the way randomUA picks a User-Agent. A proxy that fails is logged and
+// the request is retried through another one, up to proxyAttempts.
+type proxyRotator struct {
+	proxies    []string
+	transports []*http.Transport
+	log        *slog.Logger
 }
 
-func (m model) progressCmd() tea.Cmd {
-	return func() tea.Msg {
-		for p := range m.ceartax.chProg {
-			return p
+func newProxyRotator(base *http.Transport, proxies []string) (*proxyRotator, error) {
+	r := &proxyRotator{proxies: proxies}
+	for _, p := range proxies {
+		tr := base.Clone()
+		if err := useProxy(tr, p); err != nil {
+			return nil, err
 		}
-		return nil
+		r.transports = append(r.transports, tr)
 	}
+	return r, nil
 }
 
-func (m model) benchCmd() tea.Cmd {
-	return func() tea.Msg {
-		for b := range m.ceartax.chBench {
-			return b
+// pick returns a random transport, avoiding the ones in skip while any
+// other is left.
+func (r *proxyRotator) pick(skip map[int]bool) int {
+	if len(skip) >= len(r.transports) {
+		return rand.Intn(len(r.transports))
+	}
+	for {
+		if i := rand.Intn(len(r.transports)); !skip[i] {
+			return i
 		}
-		return nil
 	}
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg.(type) {
-	case tea.KeyMsg:
-		if msg.(tea.KeyMsg).String() == "ctrl+c" {
-			m.ceartax.cancel()
-			return m, tea.Quit
-		}
-	case tea.WindowSizeMsg:
-		m.width = msg.(tea.WindowSizeMsg).Width
-	case progressMsg:
-		p := msg.(progressMsg)
-		if prog, ok := m.progress[p.module]; ok {
-			prog.SetPercent(p.value)
-		} else {
-			prog = progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
-			prog.SetPercent(p.value)
-			m.progress[p.module] = prog
+func (r *proxyRotator) RoundTrip(req *http.Request) (*http.Response, error) {
+	tried := make(map[int]bool)
+	var err error
+	for attempt := 0; attempt < proxyAttempts; attempt++ {
+		if attempt > 0 {
+			if req.Body != nil && req.GetBody == nil {
+				break
+			}
+			if req.GetBody != nil {
+				body, berr := req.GetBody()
+				if berr != nil {
+					return nil, berr
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
 		}
-	case benchMsg:
-		m.benchmarks = append(m.benchmarks, msg.(benchMsg).b)
-	case doneMsg:
-		if len(m.benchmarks) >= 4 {
-			m.ready = true
-			m.saveResults()
-			return m, tea.Quit
+		i := r.pick(tried)
+		tried[i] = true
+		r.log.Debug("proxy", "url", req.URL.String(), "proxy", redactURL(r.proxies[i]), "attempt", attempt+1)
+		var resp *http.Response
+		resp, err = r.transports[i].RoundTrip(req)
+		if err == nil {
+			return resp, nil
 		}
-	case frameMsg:
-		select {
-		case <-m.repaintCh:
-		default:
+		if req.Context().Err() != nil {
+			return nil, err
 		}
+		r.log.Warn("proxy failed, trying another", "proxy", redactURL(r.proxies[i]), "err", err)
 	}
-	var cmd tea.Cmd
-	m.spinner, cmd = m.spinner.Update(msg)
-	return m, cmd
+	return nil, err
 }
 
-func (m model) View() string {
-	if !m.ready {
-		s := titleStyle.Width(m.width).Render(" CEARTAX v2.3 ") + "\n"
-		s += fmt.Sprintf("%s %s | FPS: %.1f\n\n", m.spinner.View(), m.phase, m.fps)
-
-		order := []string{"sub", "ports", "fp", "dirs"}
-		for _, k := range order {
-			if p, ok := m.progress[k]; ok {
-				label := map[string]string{"sub": "Subdomains", "ports": "Ports", "fp": "Fingerprint", "dirs": "Dirs"}[k]
-				s += barStyle.Render(fmt.Sprintf(" %s: %s\n", label, p.View()))
-			}
+// verifyReport runs on every handshake. Verification is skipped so the
+// scan can connect anyway, but we still check the chain against the system
+// roots and record in TLSInfo whether it would have been trusted.
+func (c *Ceartax) verifyReport(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	if c.certDir != "" {
+		c.mu.Lock()
+		if _, ok := c.certChains[cs.ServerName]; !ok {
+			c.certChains[cs.ServerName] = cs.PeerCertificates
 		}
-		return s
+		c.mu.Unlock()
 	}
-
+	if cs.ServerName != c.target {
+		return nil
+	}
+	opts := x509.VerifyOptions{DNSName: cs.ServerName, Intermediates: x509.NewCertPool()}
+	for _, ic := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(ic)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, seen := c.result.TLSInfo["trusted"]; seen {
+		return nil
+	}
+	c.result.TLSInfo["trusted"] = strconv.FormatBool(err == nil)
+	if err != nil {
+		c.result.TLSInfo["trust_error"] = err.Error()
+	}
+	return nil
+}
+
+// randomDelay is the old pacing: 1-2s before each probe. -rps replaces it
+// with the limiter in countRequest.
+func (c *Ceartax) randomDelay(ctx context.Context) {
+	if c.benchOnly || c.limiter != nil {
+		return
+	}
+	defer addWait(ctx, time.Now())
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Duration(rand.Intn(2)+1) * time.Second):
+	}
+}
+
+// modStats is carried in the module's context so shared helpers can count
+// work without knowing which module called them.
+type modStats struct {
+	name     string
+	requests atomic.Int64
+	errors   atomic.Int64
+	retries  atomic.Int64
+	findings atomic.Int64
+	busy     atomic.Int64 // ns spent in requests, summed over workers
+	wait     atomic.Int64 // ns spent in delays, summed over workers
+	cancel   context.CancelFunc
+	abortMsg atomic.Pointer[string]
+}
+
+type statsKey struct{}
+
+func statsOf(ctx context.Context) *modStats {
+	st, _ := ctx.Value(statsKey{}).(*modStats)
+	return st
+}
+
+// abort stops the module early; the first reason wins.
+func (st *modStats) abort(reason string) {
+	if st.abortMsg.CompareAndSwap(nil, &reason) {
+		st.cancel()
+	}
+}
+
+func countError(ctx context.Context) {
+	if st := statsOf(ctx); st != nil {
+		st.errors.Add(1)
+	}
+}
+
+func countRetry(ctx context.Context) {
+	if st := statsOf(ctx); st != nil {
+		st.retries.Add(1)
+	}
+}
+
+func addBusy(ctx context.Context, since time.Time) {
+	if st := statsOf(ctx); st != nil {
+		st.busy.Add(int64(time.Since(since)))
+	}
+}
+
+func addWait(ctx context.Context, since time.Time) {
+	if st := statsOf(ctx); st != nil {
+		st.wait.Add(int64(time.Since(since)))
+	}
+}
+
+// split divides the module's wall time d in the ratio of busy to waiting
+// worker time, so concurrent workers don't add up to more than d.
+func (st *modStats) split(d time.Duration) (active, wait time.Duration) {
+	busy, idle := st.busy.Load(), st.wait.Load()
+	if busy+idle == 0 {
+		return d, 0
+	}
+	wait = time.Duration(float64(d) * float64(idle) / float64(busy+idle))
+	return d - wait, wait
+}
+
+// allFailed is the error a module returns when it made requests and none
+// of them got an answer, e.g. the target or the resolvers are unreachable.
+func allFailed(ctx context.Context) error {
+	st := statsOf(ctx)
+	if st == nil {
+		return nil
+	}
+	if n := st.requests.Load(); n > 0 && st.errors.Load() >= n {
+		return fmt.Errorf("all %d requests failed", n)
+	}
+	return nil
+}
+
+// countRequest counts one request (HTTP, DNS query or dial) against the
+// calling module and the scan total. It runs before every network
+// operation, so it is also where a pause and the -rps limit take hold.
+func (c *Ceartax) countRequest(ctx context.Context) {
+	c.waitResume(ctx)
+	if c.limiter != nil {
+		waited := time.Now()
+		c.limiter.Wait(ctx)
+		addWait(ctx, waited)
+	}
+	c.totalRequests.Add(1)
+	if st := statsOf(ctx); st != nil {
+		st.requests.Add(1)
+	}
+}
+
+// newLogger is the -v/-vv logger. Without an out it writes into the log
+// panel, where logf already stamps the time.
+func (c *Ceartax) newLogger(out io.Writer, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if out == nil {
+		out = panelWriter{c}
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+	}
+	return slog.New(slog.NewTextHandler(out, opts)).With("scan", c.scanID[:8])
+}
+
+// panelWriter turns each line a handler writes into a log panel entry.
+type panelWriter struct{ c *Ceartax }
+
+func (w panelWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.c.logf("%s", line)
+	}
+	return len(p), nil
+}
+
+// logf adds a line to the TUI activity feed. It never blocks: without a
+// reader (headless, list-only) or with a full buffer the line is dropped.
+func (c *Ceartax) logf(format string, args ...any) {
+	select {
+	case c.chLog <- logMsg(time.Now().Format("15:04:05 ") + fmt.Sprintf(format, args...)):
+	default:
+	}
+}
+
+// TogglePause stops or restarts network activity and reports whether the
+// scan is now paused. Requests already in flight finish; the next one
+// from any module blocks until resume or cancel.
+func (c *Ceartax) TogglePause() bool {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	if c.resume == nil {
+		c.resume = make(chan struct{})
+		return true
+	}
+	close(c.resume)
+	c.resume = nil
+	return false
+}
+
+// waitResume blocks while paused; the pause counts as module wait time.
+func (c *Ceartax) waitResume(ctx context.Context) {
+	c.pauseMu.Lock()
+	ch := c.resume
+	c.pauseMu.Unlock()
+	if ch == nil {
+		return
+	}
+	defer addWait(ctx, time.Now())
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+// sleepCtx waits d or until ctx is done, reporting whether the full wait
+// elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// runBench schedules fn on the pool. With -inter-module-delay each module
+// starts that long after the previous one; the wait is not benchmarked.
+//
+// A module that returns an error (or panics) is marked FAILED and its error
+// recorded in the result; the pool has no shared context, so the other
+// modules keep running. Wait() reports the first failure.
+//
+// -module-timeout bounds each module on its own context: when it fires
+// the module is marked TIMEOUT and keeps what it found so far.
+func (c *Ceartax) runBench(name string, fn func(ctx context.Context) error) {
+	startAfter := time.Duration(c.scheduled) * c.moduleDelay
+	c.scheduled++
+	c.startModule(name, startAfter, fn)
+}
+
+// startModule runs fn in the pool with its benchmark; runBench and Rerun
+// share it.
+func (c *Ceartax) startModule(name string, startAfter time.Duration, fn func(ctx context.Context) error) {
+	c.pool.Go(func() (err error) {
+		sleepCtx(c.ctx, startAfter)
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if c.moduleLimit > 0 {
+			ctx, cancel = context.WithTimeout(c.ctx, c.moduleLimit)
+		} else {
+			ctx, cancel = context.WithCancel(c.ctx)
+		}
+		defer cancel()
+		st := &modStats{name: name, cancel: cancel}
+		b := Benchmark{
+			Module:    name,
+			Start:     time.Now(),
+			MemoryPre: c.memKB(),
+		}
+		defer func() {
+			b.End = time.Now()
+			b.Duration = b.End.Sub(b.Start)
+			b.MemoryPost = c.memKB()
+			b.DeltaKB = int64(b.MemoryPost) - int64(b.MemoryPre)
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+			b.Status = "DONE"
+			if reason := st.abortMsg.Load(); reason != nil {
+				b.Status = "ABORTED"
+				c.mu.Lock()
+				c.result.Aborted[name] = *reason
+				c.mu.Unlock()
+			} else if errors.Is(ctx.Err(), context.DeadlineExceeded) && c.ctx.Err() == nil {
+				b.Status = "TIMEOUT"
+				err = nil
+				c.mu.Lock()
+				c.result.Aborted[name] = fmt.Sprintf("-module-timeout %s reached", c.moduleLimit)
+				c.mu.Unlock()
+			} else if err != nil {
+				b.Status = "FAILED"
+				b.Error = err.Error()
+				c.mu.Lock()
+				c.result.Failed[name] = b.Error
+				c.mu.Unlock()
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			b.Requests = int(st.requests.Load())
+			b.Errors = int(st.errors.Load())
+			b.Retries = int(st.retries.Load())
+			b.ActiveDuration, b.WaitDuration = st.split(b.Duration)
+			if b.Requests > 0 && b.ActiveDuration > 0 {
+				b.RPS = float64(b.Requests) / b.ActiveDuration.Seconds()
+			}
+			c.logf("%s %s in %s", name, b.Status, b.Duration.Round(time.Millisecond))
+			c.chBench <- benchMsg{b: b}
+		}()
+		return fn(context.WithValue(ctx, statsKey{}, st))
+	})
+}
+
+func (c *Ceartax) memKB() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Alloc / 1024
+}
+
+// === HTTP HELPERS ===
+// normBasePath turns "api/v2", "/api/v2/" etc. into "/api/v2/".
+func normBasePath(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return "/"
+	}
+	return "/" + p + "/"
+}
+
+// targetURL is the https URL of rel under -base-path on the target.
+func (c *Ceartax) targetURL(rel string) string {
+	return "https://" + c.target + c.basePath + strings.TrimLeft(rel, "/")
+}
+
+// newRequest and do are the only way modules talk HTTP, so headers and
+// accounting stay in one place.
+func (c *Ceartax) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.randomUA())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	if c.tagHeader != "" {
+		// Scan ID prefix plus a sequence number: short, unique per run and
+		// greppable across runs in the target's logs.
+		req.Header.Set(c.tagHeader, fmt.Sprintf("%s-%06d", c.scanID[:8], c.tagSeq.Add(1)))
+	}
+	if c.inScope(req.URL.Hostname()) {
+		c.applyHeaders(req.Header)
+		if h := req.Header.Get("Host"); h != "" {
+			req.Host = h // net/http sends req.Host, not the header
+		}
+	}
+	return req, nil
+}
+
+// applyHeaders sets the -header/-cookie values on h, replacing what is
+// there: an explicit User-Agent beats the rotation.
+func (c *Ceartax) applyHeaders(h http.Header) {
+	for k, v := range c.headers {
+		h[k] = slices.Clone(v)
+	}
+}
+
+// localeHeaders adds -accept-language to the -header set, so it travels
+// the same scoped path as the other locale-affecting headers (a lang
+// cookie, X-Forwarded-For for geo routing). An explicit -header
+// "Accept-Language: ..." wins.
+func localeHeaders(h http.Header, acceptLang string) http.Header {
+	if acceptLang == "" || h.Get("Accept-Language") != "" {
+		return h
+	}
+	h = h.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Accept-Language", acceptLang)
+	return h
+}
+
+// inScope reports whether host is the target or one of its subdomains.
+// Session headers go nowhere else (crt.sh, off-site redirects).
+func (c *Ceartax) inScope(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == c.target || strings.HasSuffix(host, "."+c.target)
+}
+
+// headerFlag collects repeated -header "Name: Value" flags.
+type headerFlag struct{ h http.Header }
+
+func (f headerFlag) String() string {
+	if f.h == nil {
+		return ""
+	}
+	var b strings.Builder
+	f.h.Write(&b)
+	return strings.TrimSpace(b.String())
+}
+
+func (f headerFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("%q bukan \"Name: Value\"", s)
+	}
+	f.h.Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+func (c *Ceartax) do(req *http.Request) (*http.Response, error) {
+	c.countRequest(req.Context())
+	defer addBusy(req.Context(), time.Now())
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	c.noteHost(req.URL.Hostname(), err)
+	if err != nil {
+		countError(req.Context())
+		c.log.Debug("request failed", "method", req.Method, "url", req.URL.String(),
+			"ua", req.UserAgent(), "ms", durMs(time.Since(start)), "err", err)
+		return nil, err
+	}
+	c.log.Debug("request", "method", req.Method, "url", req.URL.String(), "ua", req.UserAgent(),
+		"status", resp.StatusCode, "ms", durMs(time.Since(start)))
+	cb := &countingBody{ReadCloser: resp.Body, total: &c.totalBytes}
+	resp.Body = cb
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" && req.Method != "HEAD" {
+		// Content-Encoding stays in the header as the record of what was
+		// sent; the body callers read is always the decoded one.
+		resp.Body = decodeBody(enc, cb)
+		resp.ContentLength, resp.Uncompressed = -1, true
+	}
+	return resp, nil
+}
+
+// acceptEncoding is sent on every request; do decodes each of them.
+const acceptEncoding = "gzip, deflate, br"
+
+// decodedBody undoes a Content-Encoding on first Read, so an empty body
+// (204, 304) with the header set reads as empty rather than failing.
+// Close closes the wire body, which does the byte accounting.
+type decodedBody struct {
+	raw io.ReadCloser
+	enc string
+	r   io.Reader
+	err error
+}
+
+// decodeBody wraps raw so reads return it decoded per enc (gzip, x-gzip,
+// deflate, br). A list such as "gzip, br" was applied left to right and
+// is undone right to left. An unknown encoding is passed through untouched.
+func decodeBody(enc string, raw io.ReadCloser) io.ReadCloser {
+	return &decodedBody{raw: raw, enc: strings.ToLower(strings.TrimSpace(enc))}
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		br := bufio.NewReader(b.raw)
+		if _, err := br.Peek(1); err == io.EOF {
+			return 0, io.EOF
+		}
+		b.r = br
+		encs := strings.Split(b.enc, ",")
+		for i := len(encs) - 1; i >= 0 && b.err == nil; i-- {
+			b.r, b.err = newDecoder(strings.TrimSpace(encs[i]), b.r)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+func (b *decodedBody) Close() error { return b.raw.Close() }
+
+func newDecoder(enc string, r io.Reader) (io.Reader, error) {
+	switch enc {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// The spec says zlib-wrapped, but some servers send raw deflate;
+		// a zlib header is two bytes whose big-endian value is a
+		// multiple of 31 with method 8.
+		br := bufio.NewReader(r)
+		hdr, err := br.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		if hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	}
+	return r, nil
+}
+
+const retryBase = 100 * time.Millisecond
+
+// doWithRetry is do with up to -max-retries further attempts for
+// transient failures: connection errors, timeouts and 5xx. Attempts back
+// off exponentially from retryBase with up to 50% jitter; the backoff is
+// counted as waiting. A 4xx is an answer and is returned as is.
+func (c *Ceartax) doWithRetry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(req)
+		last := attempt >= c.maxRetries || ctx.Err() != nil
+		switch {
+		case err == nil && resp.StatusCode < 500, last:
+			return resp, err
+		case err != nil && !retryable(err):
+			return nil, err
+		case err == nil:
+			resp.Body.Close()
+		}
+		d := retryBase << attempt
+		d += time.Duration(rand.Int63n(int64(d)/2 + 1))
+		waited := time.Now()
+		ok := sleepCtx(ctx, d)
+		addWait(ctx, waited)
+		if !ok {
+			return nil, ctx.Err()
+		}
+		countRetry(ctx)
+	}
+}
+
+// retryable tells a flaky connection from failures that would repeat:
+// a name that does not exist, or a service that is not HTTPS.
+func retryable(err error) bool {
+	var de *net.DNSError
+	if errors.As(err, &de) && de.IsNotFound || nonHTTPService(err) != "" {
+		return false
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// countingBody adds the body bytes actually read to the scan total. A
+// body closed unread adds nothing: the transport drops the connection
+// rather than draining it, so those bytes never crossed the wire in full.
+type countingBody struct {
+	io.ReadCloser
+	total *atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.total.Add(int64(n))
+	return n, err
+}
+
+// Page is a response whose body was read within the page budget.
+type Page struct {
+	URL       string
+	Status    int
+	Proto     string
+	Header    http.Header
+	Body      []byte
+	Truncated bool
+}
+
+func (p *Page) Hash() string {
+	sum := sha256.Sum256(p.Body)
+	return hex.EncodeToString(sum[:])
+}
+
+// PageIssue records a page cut short by -page-timeout or -page-max-bytes.
+type PageIssue struct {
+	URL   string `json:"url"`
+	Issue string `json:"issue"` // truncated, timeout
+}
+
+const defaultPageMax = 2 << 20
+
+// fetchPage is how modules read bodies. Each page gets its own deadline and
+// byte cap so one huge or stalling page cannot hold up or bloat the scan;
+// whatever arrived before the limit is still returned. host, if set,
+// overrides the Host header.
+func (c *Ceartax) fetchPage(ctx context.Context, method, u, host string) (*Page, error) {
+	parent := ctx
+	if c.pageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.pageTimeout)
+		defer cancel()
+	}
+	timedOut := func(err error) bool {
+		return errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil
+	}
+	req, err := c.newRequest(ctx, method, u)
+	if err != nil {
+		return nil, err
+	}
+	if host != "" {
+		req.Host = host
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		if timedOut(err) {
+			c.pageIssue(u, "timeout")
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	p := &Page{URL: u, Status: resp.StatusCode, Proto: resp.Proto, Header: resp.Header}
+	p.Body, p.Truncated = c.capBody(u, resp.Body, timedOut)
+	return p, nil
+}
+
+// readBody gives a response the caller already holds the page budget:
+// -page-max-bytes, and -page-timeout from now, enforced by closing the
+// body when it runs out. For bodies read long after the request went
+// out, where fetchPage's request deadline would not fit.
+func (c *Ceartax) readBody(u string, resp *http.Response) []byte {
+	var expired atomic.Bool
+	if c.pageTimeout > 0 {
+		t := time.AfterFunc(c.pageTimeout, func() {
+			expired.Store(true)
+			resp.Body.Close()
+		})
+		defer t.Stop()
+	}
+	body, _ := c.capBody(u, resp.Body, func(error) bool { return expired.Load() })
+	return body
+}
+
+// capBody reads r up to -page-max-bytes and records a cut as a page
+// issue: truncated past the cap, timeout when timedOut says the read
+// error was the page deadline.
+func (c *Ceartax) capBody(u string, r io.Reader, timedOut func(error) bool) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r, c.pageMax+1))
+	switch {
+	case int64(len(body)) > c.pageMax:
+		c.pageIssue(u, "truncated")
+		return body[:c.pageMax], true
+	case err != nil && timedOut(err):
+		c.pageIssue(u, "timeout")
+		return body, true
+	case err != nil:
+		c.log.Info("body read", "url", u, "err", err)
+	}
+	return body, false
+}
+
+func (c *Ceartax) pageIssue(u, issue string) {
+	c.mu.Lock()
+	c.result.PageIssues = append(c.result.PageIssues, PageIssue{URL: u, Issue: issue})
+	c.mu.Unlock()
+}
+
+// === DNS ===
+const dnsBench = 30 * time.Second
+
+// resolverPool round-robins queries over the -dns-servers list. A server
+// that fails (timeout, refused, SERVFAIL) is benched for dnsBench and the
+// query moves on to the next one. A nil pool uses the system resolver.
+// With a timeout, every exchange with a server gets that deadline and every
+// attempt in query is capped by it, so a dead resolver costs at most that.
+type resolverPool struct {
+	mu      sync.Mutex
+	servers []*dnsServer
+	next    int
+	timeout time.Duration
+}
+
+type dnsServer struct {
+	addr      string
+	r         *net.Resolver
+	downUntil time.Time
+}
+
+func newResolverPool(addrs []string, timeout time.Duration) *resolverPool {
+	if len(addrs) == 0 && timeout <= 0 {
+		return nil
+	}
+	p := &resolverPool{timeout: timeout}
+	if len(addrs) == 0 {
+		// Only a timeout: keep the system's servers, "" dials whichever
+		// one the resolver asks for.
+		addrs = []string{""}
+	}
+	for _, a := range addrs {
+		if _, _, err := net.SplitHostPort(a); err != nil && a != "" {
+			a = net.JoinHostPort(a, "53")
+		}
+		addr := a
+		p.servers = append(p.servers, &dnsServer{
+			addr: addr,
+			r: &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					if addr != "" {
+						address = addr
+					}
+					d := net.Dialer{Timeout: timeout}
+					conn, err := d.DialContext(ctx, network, address)
+					if err == nil && timeout > 0 {
+						conn.SetDeadline(time.Now().Add(timeout))
+					}
+					return conn, err
+				},
+			},
+		})
+	}
+	return p
+}
+
+// pick returns the next healthy server, or the next one regardless if all
+// of them are benched.
+func (p *resolverPool) pick() *dnsServer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for range p.servers {
+		s := p.servers[p.next]
+		p.next = (p.next + 1) % len(p.servers)
+		if now.After(s.downUntil) {
+			return s
+		}
+	}
+	s := p.servers[p.next]
+	p.next = (p.next + 1) % len(p.servers)
+	return s
+}
+
+func (p *resolverPool) bench(s *dnsServer) {
+	p.mu.Lock()
+	s.downUntil = time.Now().Add(dnsBench)
+	p.mu.Unlock()
+}
+
+// query runs fn against one resolver, failing over while the error looks
+// like the server's fault rather than a real answer.
+func (p *resolverPool) query(ctx context.Context, fn func(ctx context.Context, r *net.Resolver) error) error {
+	defer addBusy(ctx, time.Now())
+	if p == nil {
+		err := fn(ctx, net.DefaultResolver)
+		if err != nil && resolverFault(err) {
+			countError(ctx)
+		}
+		return err
+	}
+	var err error
+	for i := range p.servers {
+		if i > 0 {
+			countRetry(ctx)
+		}
+		s := p.pick()
+		if err = p.try(ctx, s, fn); err == nil || !resolverFault(err) || ctx.Err() != nil {
+			return err
+		}
+		p.bench(s)
+	}
+	countError(ctx)
+	return err
+}
+
+func resolverFault(err error) bool {
+	var de *net.DNSError
+	return !(errors.As(err, &de) && de.IsNotFound)
+}
+
+// try is one attempt against s, capped by the pool's timeout.
+func (p *resolverPool) try(ctx context.Context, s *dnsServer, fn func(ctx context.Context, r *net.Resolver) error) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return fn(ctx, s.r)
+}
+
+func (p *resolverPool) LookupHost(ctx context.Context, host string) (addrs []string, err error) {
+	err = p.query(ctx, func(ctx context.Context, r *net.Resolver) (e error) {
+		addrs, e = r.LookupHost(ctx, host)
+		return
+	})
+	return
+}
+
+// === MODULES ===
+var defaultSubWords = []string{"www", "api", "admin", "mail", "dev"}
+
+// wordSource feeds a module's work queue one entry at a time, so a list
+// never has to be resident all at once.
+type wordSource interface {
+	Next() (string, bool)
+	Close() error
+}
+
+type sliceSource struct {
+	words []string
+	i     atomic.Int64
+}
+
+func (s *sliceSource) Next() (string, bool) {
+	i := s.i.Add(1) - 1
+	if i >= int64(len(s.words)) {
+		return "", false
+	}
+	return s.words[i], true
+}
+
+func (s *sliceSource) Close() error { return nil }
+
+// subStreamMin is the -sub-wordlist size from which it is streamed from
+// disk instead of loaded; a few MB of labels is already ~100k strings.
+const subStreamMin = 4 << 20
+
+// fileSource reads a list file line by line with the readLines rules.
+// Only the scanner's buffer is resident, whatever the file size.
+type fileSource struct {
+	f  *os.File
+	sc *bufio.Scanner
+}
+
+func openFileSource(path string) (*fileSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSource{f: f, sc: bufio.NewScanner(f)}, nil
+}
+
+// Next is called from the single producer goroutine only.
+func (s *fileSource) Next() (string, bool) {
+	for s.sc.Scan() {
+		if l, ok := listLine(s.sc.Text()); ok {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Close reports a read error that ended Next early, if there was one.
+func (s *fileSource) Close() error {
+	err := s.sc.Err()
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// countLines counts the entries of a list file without keeping them, so
+// a streamed wordlist still has an exact progress total.
+func countLines(path string) (int, error) {
+	src, err := openFileSource(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	n := 0
+	for _, ok := src.Next(); ok; _, ok = src.Next() {
+		n++
+	}
+	return n, src.sc.Err()
+}
+
+// subWords opens the subdomain wordlist for one pass: the streamed file
+// if -sub-wordlist was large, otherwise the loaded words.
+func (c *Ceartax) subWords() (wordSource, error) {
+	if c.subWordFile != "" {
+		return openFileSource(c.subWordFile)
+	}
+	return &sliceSource{words: c.subWordlist}, nil
+}
+
+// subWordTotal is the number of subdomain words, loaded or streamed.
+func (c *Ceartax) subWordTotal() int {
+	if c.subWordFile != "" {
+		return c.subWordCount
+	}
+	return len(c.subWordlist)
+}
+
+// subLookupCap bounds the lookups of one Subdomains run; with
+// -recurse-depth every find multiplies the next round by the wordlist.
+const subLookupCap = 100000
+
+// Subdomains brutes the wordlist under the target, then with
+// -recurse-depth under every new host the previous round found, until
+// the depth, the lookup cap or a round without finds ends it. Progress
+// gives each round an equal share so the bar never moves back.
+func (c *Ceartax) Subdomains(ctx context.Context) error {
+	defer c.moduleDone()
+	baseline := ""
+	if !c.listOnly {
+		baseline = c.catchAllHash(ctx)
+	}
+	parents := []string{c.target}
+	lookups := 0
+	for round := 0; round < c.recurseDepth && len(parents) > 0 && ctx.Err() == nil; round++ {
+		budget := subLookupCap - lookups
+		if budget <= 0 {
+			c.logf("Subdomains: %d lookups, stopping before depth %d", lookups, round+1)
+			break
+		}
+		var n int
+		var err error
+		parents, n, err = c.subRound(ctx, round, parents, baseline, budget)
+		lookups += n
+		if err != nil {
+			c.chProg <- progressMsg{module: "sub", value: 1.0}
+			return fmt.Errorf("-sub-wordlist: %w", err)
+		}
+	}
+	return allFailed(ctx)
+}
+
+// subRound streams parent x word through a bounded channel to workers;
+// every lookup also holds a c.sem slot. It returns the hosts that were
+// new, how many lookups it handed out, and why the wordlist could not be
+// read to the end, if it could not.
+func (c *Ceartax) subRound(ctx context.Context, round int, parents []string, baseline string, budget int) ([]string, int, error) {
+	total := min(len(parents)*c.subWordTotal(), budget)
+	hosts := make(chan string, 256)
+	// Written by the producer before it closes hosts, read after wg.Wait.
+	var (
+		sent    int
+		readErr error
+	)
+	go func() {
+		defer close(hosts)
+		for _, parent := range parents {
+			src, err := c.subWords()
+			if err != nil {
+				readErr = err
+				return
+			}
+			for w, ok := src.Next(); ok && sent < total; w, ok = src.Next() {
+				select {
+				case hosts <- w + "." + parent:
+					sent++
+				case <-ctx.Done():
+					src.Close()
+					return
+				}
+			}
+			if err := src.Close(); err != nil {
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	// Progress counts finished probes, not hosts handed to the queue.
+	var (
+		done  atomic.Int64
+		mu    sync.Mutex
+		found []string
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range hosts {
+				if c.probeSub(ctx, h, baseline) {
+					mu.Lock()
+					found = append(found, h)
+					mu.Unlock()
+				}
+				frac := float64(done.Add(1)) / float64(total)
+				c.chProg <- progressMsg{module: "sub", value: (float64(round) + frac) / float64(c.recurseDepth)}
+			}
+		}()
+	}
+	wg.Wait()
+	return found, sent, readErr
+}
+
+// probeSub resolves host and reports whether it was a new subdomain.
+func (c *Ceartax) probeSub(ctx context.Context, host, baseline string) bool {
+	if c.excludeHosts[host] {
+		c.suppress(host, "excluded", "listed in -exclude-subdomains")
+		return false
+	}
+	// Pace before taking a slot: a sleeping worker must not hold one.
+	c.randomDelay(ctx)
+	select {
+	case c.sem <- struct{}{}:
+		defer func() { <-c.sem }()
+	case <-ctx.Done():
+		return false
+	}
+	c.countRequest(ctx)
+	addrs, err := c.dns.LookupHost(ctx, host)
+	c.log.Debug("lookup", "host", host, "addrs", addrs, "err", err)
+	if err != nil {
+		return false
+	}
+	isNew := c.addSubdomain(host, addrs)
+	if isNew {
+		c.emit(ctx, "subdomain", host)
+	}
+	if !c.listOnly && c.isAlive(ctx, host, baseline) {
+		c.mu.Lock()
+		if !slices.Contains(c.result.LiveSubdomains, host) { // a re-run probes known hosts again
+			c.result.LiveSubdomains = append(c.result.LiveSubdomains, host)
+		}
+		c.mu.Unlock()
+	}
+	return isNew
+}
+
+// addSubdomain records host once, whichever discovery module finds it
+// first, and reports whether it was new.
+func (c *Ceartax) addSubdomain(host string, addrs []string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, seen := c.result.SubdomainIPs[host]; seen {
+		return false
+	}
+	c.result.Subdomains = append(c.result.Subdomains, host)
+	c.result.SubdomainIPs[host] = sortAddrs(addrs)
+	return true
+}
+
+// sortAddrs orders resolved addresses IPv4 first, then numerically, so
+// the report does not change with resolver answer order.
+func sortAddrs(addrs []string) []string {
+	out := slices.Clone(addrs)
+	slices.SortFunc(out, func(a, b string) int {
+		pa, errA := netip.ParseAddr(a)
+		pb, errB := netip.ParseAddr(b)
+		if errA != nil || errB != nil {
+			return strings.Compare(a, b)
+		}
+		return pa.Compare(pb)
+	})
+	return out
+}
+
+// crtShMax caps the crt.sh answer; large domains return tens of MB.
+const crtShMax = 64 << 20
+
+// CrtSh is passive discovery from certificate transparency: names on
+// certificates logged for *.target. Only names that resolve are kept, as
+// for brute force; nothing is sent to the hosts themselves.
+func (c *Ceartax) CrtSh(ctx context.Context) error {
+	defer c.moduleDone()
+	defer func() { c.chProg <- progressMsg{module: "crt", value: 1.0} }()
+	names, err := c.crtShNames(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	hosts := make(chan string)
+	go func() {
+		defer close(hosts)
+		for _, h := range names {
+			select {
+			case hosts <- h:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var done atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.concurrency, len(names)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range hosts {
+				c.resolvePassive(ctx, h)
+				c.chProg <- progressMsg{module: "crt", value: float64(done.Add(1)) / float64(len(names))}
+			}
+		}()
+	}
+	wg.Wait()
+	return allFailed(ctx)
+}
+
+func (c *Ceartax) resolvePassive(ctx context.Context, host string) {
+	if c.excludeHosts[host] {
+		c.suppress(host, "excluded", "listed in -exclude-subdomains")
+		return
+	}
+	select {
+	case c.sem <- struct{}{}:
+		defer func() { <-c.sem }()
+	case <-ctx.Done():
+		return
+	}
+	c.countRequest(ctx)
+	addrs, err := c.dns.LookupHost(ctx, host)
+	c.log.Debug("lookup", "host", host, "addrs", addrs, "err", err)
+	if err != nil {
+		return
+	}
+	if c.addSubdomain(host, addrs) {
+		c.emit(ctx, "subdomain", host)
+	}
+}
+
+// crtShNames returns the unique subdomains of the target named in CT
+// logs, wildcards stripped. crt.sh answers overload with an HTML page
+// (often with status 200), so anything that is not a JSON array is an
+// error rather than an empty result.
+func (c *Ceartax) crtShNames(ctx context.Context) ([]string, error) {
+	req, err := c.newRequest(ctx, "GET", "https://crt.sh/?q="+url.QueryEscape("%."+c.target)+"&output=json")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, crtShMax))
+	if err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	if resp.StatusCode != http.StatusOK || len(body) == 0 || body[0] != '[' {
+		return nil, fmt.Errorf("crt.sh: HTTP %d, %s instead of JSON", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var entries []struct {
+		NameValue string `json:"name_value"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, e := range entries {
+		for _, n := range strings.Split(e.NameValue, "\n") {
+			n = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(n), "."))
+			n = strings.TrimPrefix(n, "*.")
+			if !strings.HasSuffix(n, "."+c.target) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DNS records the target's MX, TXT, NS, CNAME and SOA records. SPF (a
+// v=spf1 TXT on the target) and DMARC (v=DMARC1 on _dmarc) get keys of
+// their own and are emitted as findings. A name without records of a
+// type is not an error.
+func (c *Ceartax) DNS(ctx context.Context) error {
+	defer c.moduleDone()
+	recs := make(map[string][]string)
+	lookups := []struct {
+		typ string
+		fn  func(ctx context.Context, r *net.Resolver) ([]string, error)
+	}{
+		{"MX", func(ctx context.Context, r *net.Resolver) ([]string, error) {
+			mx, err := r.LookupMX(ctx, c.target)
+			var out []string
+			for _, m := range mx {
+				out = append(out, fmt.Sprintf("%d %s", m.Pref, m.Host))
+			}
+			return out, err
+		}},
+		{"NS", func(ctx context.Context, r *net.Resolver) ([]string, error) {
+			ns, err := r.LookupNS(ctx, c.target)
+			var out []string
+			for _, n := range ns {
+				out = append(out, n.Host)
+			}
+			return out, err
+		}},
+		{"TXT", func(ctx context.Context, r *net.Resolver) ([]string, error) {
+			return r.LookupTXT(ctx, c.target)
+		}},
+		{"CNAME", func(ctx context.Context, r *net.Resolver) ([]string, error) {
+			cname, err := r.LookupCNAME(ctx, c.target)
+			if err != nil || strings.EqualFold(strings.TrimSuffix(cname, "."), c.target) {
+				return nil, err
+			}
+			return []string{cname}, nil
+		}},
+		{"DMARC", func(ctx context.Context, r *net.Resolver) ([]string, error) {
+			return r.LookupTXT(ctx, "_dmarc."+c.target)
+		}},
+	}
+	for i, l := range lookups {
+		c.chProg <- progressMsg{module: "dns", value: float64(i) / float64(len(lookups)+1)}
+		c.countRequest(ctx)
+		var vals []string
+		err := c.dns.query(ctx, func(ctx context.Context, r *net.Resolver) (e error) {
+			vals, e = l.fn(ctx, r)
+			return
+		})
+		if err != nil || len(vals) == 0 {
+			continue
+		}
+		recs[l.typ] = vals
+	}
+	for _, t := range recs["TXT"] {
+		if strings.HasPrefix(strings.ToLower(t), "v=spf1") {
+			recs["SPF"] = append(recs["SPF"], t)
+		}
+	}
+	dmarc := recs["DMARC"][:0:0]
+	for _, t := range recs["DMARC"] {
+		if strings.HasPrefix(strings.ToUpper(t), "V=DMARC1") {
+			dmarc = append(dmarc, t)
+		}
+	}
+	delete(recs, "DMARC")
+	if len(dmarc) > 0 {
+		recs["DMARC"] = dmarc
+	}
+
+	c.chProg <- progressMsg{module: "dns", value: float64(len(lookups)) / float64(len(lookups)+1)}
+	if ns := recs["NS"]; len(ns) > 0 {
+		if soa, err := c.lookupSOA(ctx, ns[0]); err == nil {
+			recs["SOA"] = []string{soa}
+		}
+	}
+	c.chProg <- progressMsg{module: "dns", value: 1.0}
+
+	c.mu.Lock()
+	c.result.DNSRecords = recs
+	c.mu.Unlock()
+	for _, typ := range []string{"SPF", "DMARC"} {
+		for _, v := range recs[typ] {
+			c.emit(ctx, strings.ToLower(typ), v)
+		}
+	}
+	return allFailed(ctx)
+}
+
+// lookupSOA asks the zone's name server ns directly, since net.Resolver
+// has no SOA lookup. Over UDP, so neither -proxy nor -dns-servers apply.
+func (c *Ceartax) lookupSOA(ctx context.Context, ns string) (string, error) {
+	c.countRequest(ctx)
+	defer addBusy(ctx, time.Now())
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	name, err := dnsmessage.NewName(strings.TrimSuffix(c.target, ".") + ".")
+	if err != nil {
+		return "", err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	q := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := q.Pack()
+	if err != nil {
+		return "", err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", net.JoinHostPort(strings.TrimSuffix(ns, "."), "53"))
+	if err != nil {
+		countError(ctx)
+		return "", err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	if _, err := conn.Write(packed); err != nil {
+		countError(ctx)
+		return "", err
+	}
+	buf := make([]byte, 1232)
+	n, err := conn.Read(buf)
+	if err != nil {
+		countError(ctx)
+		return "", err
+	}
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf[:n]); err != nil {
+		return "", err
+	}
+	if resp.ID != id {
+		return "", errors.New("SOA: reply ID mismatch")
+	}
+	for _, a := range append(resp.Answers, resp.Authorities...) {
+		if soa, ok := a.Body.(*dnsmessage.SOAResource); ok {
+			return fmt.Sprintf("%s %s %d %d %d %d %d", soa.NS, soa.MBox, soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.MinTTL), nil
+		}
+	}
+	return "", errors.New("SOA: no record in reply")
+}
+
+// catchAllHash fingerprints what the infrastructure serves for a vhost
+// that cannot exist: via wildcard DNS if there is one, otherwise by
+// sending a bogus Host header to the target itself.
+func (c *Ceartax) catchAllHash(ctx context.Context) string {
+	bogus := fmt.Sprintf("ceartax-%08x.%s", rand.Uint32(), c.target)
+	ca := &CatchAll{Probe: bogus}
+	if p, err := c.fetchPage(ctx, "GET", "https://"+bogus+"/", ""); err == nil {
+		ca.Method, ca.Hash = "wildcard-dns", p.Hash()
+		c.countRequest(ctx)
+		var err error
+		if ca.IPs, err = c.dns.LookupHost(ctx, bogus); err != nil {
+			c.log.Info("catch-all lookup", "host", bogus, "err", err)
+		}
+	} else if p, err := c.fetchPage(ctx, "GET", "https://"+c.target+"/", bogus); err == nil {
+		ca.Method, ca.Hash = "host-header", p.Hash()
+	} else {
+		return ""
+	}
+	c.mu.Lock()
+	c.suppressionLog().CatchAll = ca
+	c.mu.Unlock()
+	return ca.Hash
+}
+
+// suppressionLog creates the result section on first use; callers hold mu.
+func (c *Ceartax) suppressionLog() *SuppressionLog {
+	if c.result.SuppressionLog == nil {
+		c.result.SuppressionLog = &SuppressionLog{}
+	}
+	return c.result.SuppressionLog
+}
+
+func (c *Ceartax) suppress(host, reason, evidence string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sl := c.suppressionLog()
+	sl.Hosts = append(sl.Hosts, Suppressed{Host: host, Reason: reason, Evidence: evidence})
+}
+
+// isAlive reports whether host serves something of its own, i.e. neither
+// an -alive-exclude-codes status nor the catch-all page. Hosts rejected
+// for either reason go to the suppression log.
+func (c *Ceartax) isAlive(ctx context.Context, host, baseline string) bool {
+	for _, scheme := range []string{"https://", "http://"} {
+		p, err := c.fetchPage(ctx, "GET", scheme+host+"/", "")
+		if err != nil {
+			continue
+		}
+		switch {
+		case c.aliveExclude[p.Status]:
+			c.suppress(host, "status", fmt.Sprintf("%s answered %d (-alive-exclude-codes)", p.URL, p.Status))
+			return false
+		case baseline != "" && p.Hash() == baseline:
+			c.suppress(host, "catch-all", "body hash matches catch-all baseline "+baseline)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// hostPort is one dial of the port scan.
+type hostPort struct {
+	host string
+	port int
+}
+
+// portList is -ports (80, 443, 22 by default) minus -exclude-ports.
+func (c *Ceartax) portList() []int {
+	var ports []int
+	scan := c.ports
+	if len(scan) == 0 {
+		scan = []int{80, 443, 22}
+	}
+	for _, p := range scan {
+		if !c.excludePorts[p] {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// rangePorts flattens HostPorts, ordered by address then port.
+func (r *ReconResult) rangePorts() []hostPort {
+	addrs := make([]string, 0, len(r.HostPorts))
+	for a := range r.HostPorts {
+		addrs = append(addrs, a)
+	}
+	var out []hostPort
+	for _, a := range sortAddrs(addrs) {
+		for _, p := range r.HostPorts[a] {
+			out = append(out, hostPort{a, p})
+		}
+	}
+	return out
+}
+
+// Ports dials in batches of c.portBatch; every dial also holds a slot of
+// c.sem so batches never exceed the global concurrency. For a CIDR target
+// every address gets every port and results go to HostPorts; connect
+// times and banners are only kept for a single host, where the port alone
+// is a unique key.
+func (c *Ceartax) Ports(ctx context.Context) error {
+	defer c.moduleDone()
+	ports := c.portList()
+	hosts := c.rangeHosts
+	if len(hosts) == 0 {
+		hosts = []string{c.target}
+	}
+	var jobs []hostPort
+	for _, h := range hosts {
+		for _, p := range ports {
+			jobs = append(jobs, hostPort{h, p})
+		}
+	}
+	total := float64(len(jobs))
+	var done int64
+	for start := 0; start < len(jobs) && ctx.Err() == nil; start += c.portBatch {
+		c.randomDelay(ctx)
+		var wg sync.WaitGroup
+		for _, j := range jobs[start:min(start+c.portBatch, len(jobs))] {
+			select {
+			case c.sem <- struct{}{}:
+			case <-ctx.Done():
+				continue
+			}
+			wg.Add(1)
+			go func(j hostPort) {
+				defer func() { <-c.sem; wg.Done() }()
+				p := j.port
+				open, rtt, banner := c.dialPort(ctx, j.host, p)
+				if open && c.rangeHosts != nil {
+					c.mu.Lock()
+					if c.result.HostPorts == nil {
+						c.result.HostPorts = make(map[string][]int)
+					}
+					c.result.HostPorts[j.host] = append(c.result.HostPorts[j.host], p)
+					c.mu.Unlock()
+					c.emit(ctx, "port", net.JoinHostPort(j.host, strconv.Itoa(p)))
+				} else if open {
+					c.mu.Lock()
+					c.result.OpenPorts = append(c.result.OpenPorts, p)
+					c.result.ConnectMs[p] = durMs(rtt)
+					if banner != "" {
+						c.result.Banners[p] = banner
+					}
+					c.mu.Unlock()
+					c.emit(ctx, "port", strconv.Itoa(p))
+				} else if rtt > 0 && c.timeClosed && c.rangeHosts == nil {
+					c.mu.Lock()
+					if c.result.ClosedMs == nil {
+						c.result.ClosedMs = make(map[int]float64)
+					}
+					c.result.ClosedMs[p] = durMs(rtt)
+					c.mu.Unlock()
+				}
+				n := atomic.AddInt64(&done, 1)
+				c.chProg <- progressMsg{module: "ports", value: float64(n) / total}
+			}(j)
+		}
+		wg.Wait()
+	}
+	c.mu.Lock()
+	sort.Ints(c.result.OpenPorts)
+	for _, ps := range c.result.HostPorts {
+		sort.Ints(ps)
+	}
+	c.mu.Unlock()
+	return allFailed(ctx)
+}
+
+// bannerLine turns what a service sent back into one readable line. HTTP
+// replies are parsed just far enough for the status line and Server header,
+// so chunk framing and (possibly compressed) bodies never leak into the
+// banner; anything else falls back to the first line of the raw bytes.
+func bannerLine(raw []byte) string {
+	if bytes.HasPrefix(raw, []byte("HTTP/")) {
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), nil)
+		if err == nil {
+			resp.Body.Close()
+			line := resp.Proto + " " + resp.Status
+			if srv := resp.Header.Get("Server"); srv != "" {
+				line += " | Server: " + srv
+			}
+			return line
+		}
+		// Headers cut off by the read limit: keep the status line.
+	}
+	line, _, _ := bytes.Cut(raw, []byte("\n"))
+	return strings.TrimSpace(string(line))
+}
+
+// dialPort reports whether p is open, how long the host took to answer
+// the SYN and what the service said. rtt is zero when nothing answered
+// (filtered or dial error).
+func (c *Ceartax) dialPort(ctx context.Context, host string, p int) (open bool, rtt time.Duration, banner string) {
+	c.countRequest(ctx)
+	start := time.Now()
+	defer addBusy(ctx, start)
+	d := net.Dialer{Timeout: c.dialTimeout}
+	conn, err := d.DialContext(ctx, c.network, net.JoinHostPort(host, strconv.Itoa(p)))
+	c.log.Debug("dial", "addr", net.JoinHostPort(host, strconv.Itoa(p)), "ms", durMs(time.Since(start)), "err", err)
+	if err != nil {
+		// Refused and timed out are answers (closed/filtered), not errors.
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			c.noteHost(host, nil)
+			return false, time.Since(start), ""
+		}
+		var ne net.Error
+		if !(errors.As(err, &ne) && ne.Timeout()) && ctx.Err() == nil {
+			countError(ctx)
+			c.noteHost(host, err)
+		}
+		return false, 0, ""
+	}
+	c.noteHost(host, nil)
+	rtt = time.Since(start)
+	defer conn.Close()
+	if c.rangeHosts != nil {
+		return true, rtt, "" // banners are not kept for ranges, don't wait for them
+	}
+	return true, rtt, c.grabBanner(ctx, conn, p)
+}
+
+// httpPorts get a HEAD probe before the banner read; other services are
+// expected to speak first (SSH, SMTP, FTP, ...). TLS ports are skipped,
+// a plaintext probe only gets an alert back.
+var httpPorts = map[int]bool{80: true, 3000: true, 5000: true, 8000: true, 8008: true, 8080: true, 8081: true, 8888: true, 9000: true}
+
+// bannerBuf is how much of a banner is read without -read-buffer.
+const bannerBuf = 1024
+
+// grabBanner reads up to bannerBuf bytes (or -read-buffer) within
+// -dial-timeout. Scan cancellation closes the connection, so a silent
+// port never outlives the scan.
+func (c *Ceartax) grabBanner(ctx context.Context, conn net.Conn, p int) string {
+	if p == 443 || p == 8443 {
+		return ""
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	conn.SetDeadline(time.Now().Add(c.dialTimeout))
+	if httpPorts[p] {
+		if _, err := io.WriteString(conn, "HEAD / HTTP/1.0\r\n\r\n"); err != nil {
+			return ""
+		}
+	}
+	size := bannerBuf
+	if c.readBuffer > 0 {
+		size = c.readBuffer
+		if tc, ok := conn.(*net.TCPConn); ok {
+			tc.SetReadBuffer(c.readBuffer)
+		}
+	}
+	buf := make([]byte, size)
+	n, _ := io.ReadAtLeast(conn, buf, 1)
+	if n == 0 {
+		return ""
+	}
+	// HTTP/1.0 replies end with the connection, so keep reading (bounded by
+	// the deadline) to get the Server header along with the status line.
+	if httpPorts[p] {
+		m, _ := io.ReadFull(conn, buf[n:])
+		n += m
+	}
+	return c.cleanValue(bannerLine(buf[:n]))
+}
+
+// noteHost tracks consecutive connection failures per host for
+// -max-consecutive-failures; any answer resets the count. When the scan
+// target itself hits the limit the scan is cut short, since every module
+// is talking to it. Cancellations are ours, not the host's, and don't count.
+func (c *Ceartax) noteHost(host string, err error) {
+	if c.maxFails <= 0 || errors.Is(err, context.Canceled) {
+		return
+	}
+	v, _ := c.hostFails.LoadOrStore(host, new(atomic.Int64))
+	n := v.(*atomic.Int64)
+	if err == nil {
+		n.Store(0)
+		return
+	}
+	if n.Add(1) != int64(c.maxFails) {
+		return
+	}
+	c.mu.Lock()
+	if c.result.Unreachable == nil {
+		c.result.Unreachable = make(map[string]string)
+	}
+	c.result.Unreachable[host] = fmt.Sprintf("%d consecutive connection failures, last: %v", c.maxFails, err)
+	c.mu.Unlock()
+	if host == c.target {
+		c.cancel()
+	}
+}
+
+// durMs is d in milliseconds with two decimals, enough for LAN latencies.
+func durMs(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
+
+func (c *Ceartax) Fingerprint(ctx context.Context) error {
+	defer c.moduleDone()
+	req, err := c.newRequest(context.WithValue(ctx, keepLastRedirect{}, true), "GET", c.targetURL(""))
+	if err != nil {
+		return err
+	}
+	defer func() { c.chProg <- progressMsg{module: "fp", value: 1.0} }()
+	// net/http rejects or normalizes exactly the replies worth flagging,
+	// so look at the wire bytes first.
+	c.inspectRaw(ctx)
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		if svc := nonHTTPService(err); svc != "" {
+			c.mu.Lock()
+			c.result.TechStack["service"] = svc
+			c.mu.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	chain := redirectChain(resp)
+	c.mu.Lock()
+	c.result.FinalURL = chain[len(chain)-1]
+	c.result.ContentEncoding = resp.Header.Get("Content-Encoding")
+	if len(chain) > 1 {
+		c.result.RedirectChain = chain
+	}
+	for k, v := range resp.Header {
+		vals := make([]string, len(v))
+		for i, x := range v {
+			vals[i] = c.cleanValue(x)
+		}
+		c.result.Headers[c.cleanValue(strings.ToLower(k))] = vals
+	}
+	c.result.TechStack["http_version"] = resp.Proto
+	c.mu.Unlock()
+	c.detectTech(ctx, resp.Header)
+	h2, why := c.probeHTTP2(ctx)
+	if !h2 {
+		c.log.Info("HTTP/2 not supported", "target", c.target, "why", why)
+	}
+	h3 := altSvcH3(resp.Header)
+	if !h3 {
+		c.log.Info("HTTP/3 not advertised", "target", c.target)
+	}
+	c.mu.Lock()
+	c.result.HTTPVersions = map[string]bool{"HTTP/2": h2, "HTTP/3": h3}
+	c.mu.Unlock()
+	if c.grep != nil {
+		u := chain[len(chain)-1]
+		c.grepBody(ctx, u, c.readBody(u, resp))
+	}
+
+	if c.respectRobots {
+		rules, err := c.robotsRules(ctx)
+		if err != nil {
+			c.log.Info("robots", "err", err)
+		}
+		c.mu.Lock()
+		for _, r := range rules {
+			c.result.RobotsDisallow = append(c.result.RobotsDisallow, r.path)
+		}
+		c.mu.Unlock()
+	}
+	if c.methodFuzz {
+		c.fuzzMethods(ctx)
+	}
+	if c.lbSamples > 1 {
+		c.detectLB(ctx)
+	}
+	return nil
+}
+
+// probeHTTP2 offers h2 in ALPN on 443 and, if the server picks it, sends
+// HEAD over an HTTP/2 connection, so a server that only claims h2 in the
+// handshake does not count. why says where it stopped otherwise.
+func (c *Ceartax) probeHTTP2(ctx context.Context) (ok bool, why string) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	c.countRequest(ctx)
+	defer addBusy(ctx, time.Now())
+	raw, err := c.dial(ctx, net.JoinHostPort(c.target, "443"))
+	if err != nil {
+		countError(ctx)
+		return false, err.Error()
+	}
+	conn := tls.Client(raw, &tls.Config{InsecureSkipVerify: true, ServerName: c.target, NextProtos: []string{"h2", "http/1.1"}})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		countError(ctx)
+		return false, err.Error()
+	}
+	switch p := conn.ConnectionState().NegotiatedProtocol; p {
+	case "h2":
+	case "":
+		return false, "server ignored ALPN"
+	default:
+		return false, "server chose " + p
+	}
+	cc, err := (&http2.Transport{}).NewClientConn(conn)
+	if err != nil {
+		return false, err.Error()
+	}
+	defer cc.Close()
+	req, err := c.newRequest(ctx, "HEAD", c.targetURL(""))
+	if err != nil {
+		return false, err.Error()
+	}
+	resp, err := cc.RoundTrip(req)
+	if err != nil {
+		countError(ctx)
+		return false, err.Error()
+	}
+	resp.Body.Close()
+	return true, ""
+}
+
+// altSvcH3 reports whether Alt-Svc advertises HTTP/3 (h3, or a draft
+// such as h3-29). There is no QUIC client here to confirm it, so this is
+// the server's word only.
+func altSvcH3(h http.Header) bool {
+	for _, v := range h.Values("Alt-Svc") {
+		for _, alt := range strings.Split(v, ",") {
+			id, _, _ := strings.Cut(strings.TrimSpace(alt), "=")
+			if id == "h3" || strings.HasPrefix(id, "h3-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// grepMaxHits caps -grep matches per page, grepMaxLen the length of each
+// recorded match; a loose pattern on a big page would flood the report.
+const (
+	grepMaxHits = 20
+	grepMaxLen  = 200
+)
+
+// grepBody records every -grep match in body (read up to -page-max-bytes).
+func (c *Ceartax) grepBody(ctx context.Context, u string, body []byte) {
+	for _, m := range c.grep.FindAll(body, grepMaxHits) {
+		text := c.cleanValue(string(m[:min(len(m), grepMaxLen)]))
+		c.mu.Lock()
+		c.result.Matches = append(c.result.Matches, Match{URL: u, Text: text})
+		c.mu.Unlock()
+		c.emit(ctx, "match", u+" "+text)
+	}
+}
+
+// techSignatures maps "header/category/product" to a pattern matched
+// against each value of that header. An optional first group is the
+// version. Add a line to recognize another product.
+var techSignatures = map[string]*regexp.Regexp{
+	"server/webserver/nginx":             regexp.MustCompile(`(?i)^nginx(?:/([\d.]+))?`),
+	"server/webserver/apache":            regexp.MustCompile(`(?i)^apache(?:/([\d.]+))?`),
+	"server/webserver/iis":               regexp.MustCompile(`(?i)^microsoft-iis(?:/([\d.]+))?`),
+	"server/webserver/openresty":         regexp.MustCompile(`(?i)^openresty(?:/([\d.]+))?`),
+	"server/webserver/litespeed":         regexp.MustCompile(`(?i)^litespeed`),
+	"server/webserver/caddy":             regexp.MustCompile(`(?i)^caddy`),
+	"server/cdn/cloudflare":              regexp.MustCompile(`(?i)^cloudflare`),
+	"x-powered-by/language/php":          regexp.MustCompile(`(?i)\bphp(?:/([\d.]+))?`),
+	"x-powered-by/framework/asp.net":     regexp.MustCompile(`(?i)^asp\.net`),
+	"x-powered-by/framework/express":     regexp.MustCompile(`(?i)^express`),
+	"x-powered-by/framework/next.js":     regexp.MustCompile(`(?i)^next\.js(?: ([\d.]+))?`),
+	"x-aspnet-version/framework/asp.net": regexp.MustCompile(`^([\d.]+)`),
+	"x-generator/cms/wordpress":          regexp.MustCompile(`(?i)^wordpress(?: ([\d.]+))?`),
+	"x-generator/cms/drupal":             regexp.MustCompile(`(?i)^drupal(?: ([\d.]+))?`),
+	"x-generator/cms/joomla":             regexp.MustCompile(`(?i)^joomla!?(?: ([\d.]+))?`),
+	"x-drupal-cache/cms/drupal":          regexp.MustCompile(`.`),
+	"set-cookie/framework/laravel":       regexp.MustCompile(`(?i)^laravel_session=`),
+	"set-cookie/framework/asp.net":       regexp.MustCompile(`(?i)^ASP\.NET_SessionId=`),
+	"set-cookie/language/php":            regexp.MustCompile(`(?i)^PHPSESSID=`),
+	"set-cookie/language/java":           regexp.MustCompile(`(?i)^JSESSIONID=`),
+	"set-cookie/cms/wordpress":           regexp.MustCompile(`(?i)^wordpress_`),
+}
+
+// detectTech fills TechStack from the response headers, one product per
+// category (webserver, language, framework, cms, cdn). Signatures are
+// tried in key order; a later hit only replaces an earlier one if it
+// adds a version. Each product is also a "tech" finding.
+func (c *Ceartax) detectTech(ctx context.Context, h http.Header) {
+	keys := make([]string, 0, len(techSignatures))
+	for k := range techSignatures {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	found := make(map[string]string)
+	for _, k := range keys {
+		parts := strings.SplitN(k, "/", 3)
+		header, category, product := parts[0], parts[1], parts[2]
+		for _, v := range h.Values(header) {
+			m := techSignatures[k].FindStringSubmatch(v)
+			if m == nil {
+				continue
+			}
+			tech := product
+			if len(m) > 1 && m[1] != "" {
+				tech += " " + m[1]
+			}
+			if old, ok := found[category]; !ok || !strings.Contains(old, " ") && strings.Contains(tech, " ") {
+				found[category] = tech
+			}
+			break
+		}
+	}
+	categories := make([]string, 0, len(found))
+	c.mu.Lock()
+	for category, tech := range found {
+		c.result.TechStack[category] = tech
+		categories = append(categories, category)
+	}
+	c.mu.Unlock()
+	sort.Strings(categories)
+	for _, category := range categories {
+		c.emit(ctx, "tech", category+": "+found[category])
+	}
+}
+
+// iconLink finds <link rel="...icon..." href="..."> in either attribute
+// order.
+var iconLink = regexp.MustCompile(`(?is)<link\b[^>]*?(?:rel\s*=\s*["']?[^"'>]*\bicon\b[^>]*?href\s*=\s*["']?([^"'\s>]+)|href\s*=\s*["']?([^"'\s>]+)[^>]*?rel\s*=\s*["']?[^"'>]*\bicon\b)`)
+
+// Favicon hashes the site icon the way Shodan does (mmh3 of the MIME
+// base64 body, as a signed int) so it can be looked up in favicon
+// databases. /favicon.ico is tried first, then the icon the home page
+// links to.
+func (c *Ceartax) Favicon(ctx context.Context) error {
+	defer c.moduleDone()
+	defer func() { c.chProg <- progressMsg{module: "fav", value: 1.0} }()
+	root := "https://" + c.target + "/"
+	p, err := c.fetchPage(ctx, "GET", root+"favicon.ico", "")
+	if err != nil || p.Status != http.StatusOK || len(p.Body) == 0 {
+		c.chProg <- progressMsg{module: "fav", value: 0.5}
+		home, err := c.fetchPage(ctx, "GET", c.targetURL(""), "")
+		if err != nil {
+			return allFailed(ctx)
+		}
+		m := iconLink.FindSubmatch(home.Body)
+		if m == nil {
+			return nil
+		}
+		href := string(m[1]) + string(m[2])
+		base, _ := url.Parse(home.URL)
+		ref, err := url.Parse(html.UnescapeString(href))
+		if err != nil {
+			return nil
+		}
+		if p, err = c.fetchPage(ctx, "GET", base.ResolveReference(ref).String(), ""); err != nil || p.Status != http.StatusOK || len(p.Body) == 0 {
+			return allFailed(ctx)
+		}
+	}
+	hash := strconv.Itoa(int(int32(murmur3(mimeBase64(p.Body)))))
+	c.mu.Lock()
+	c.result.TechStack["favicon_mmh3"] = hash
+	c.mu.Unlock()
+	c.emit(ctx, "favicon-mmh3", hash)
+	return nil
+}
+
+// mimeBase64 is Python's base64.encodebytes, which Shodan hashes: lines
+// of 76 characters, each ending in a newline.
+func mimeBase64(b []byte) []byte {
+	enc := base64.StdEncoding.EncodeToString(b)
+	var out bytes.Buffer
+	for len(enc) > 76 {
+		out.WriteString(enc[:76])
+		out.WriteByte('\n')
+		enc = enc[76:]
+	}
+	out.WriteString(enc)
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+// murmur3 is MurmurHash3 x86_32 with seed 0.
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	n := len(data) / 4 * 4
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	switch tail := data[n:]; len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// wafSignatures maps "header/vendor" to a pattern on that header's
+// values, in the style of techSignatures.
+var wafSignatures = map[string]*regexp.Regexp{
+	"cf-ray/cloudflare":            regexp.MustCompile(`.`),
+	"server/cloudflare":            regexp.MustCompile(`(?i)^cloudflare`),
+	"set-cookie/cloudflare":        regexp.MustCompile(`^__cf_bm=|^__cfduid=`),
+	"x-amz-cf-id/cloudfront":       regexp.MustCompile(`.`),
+	"via/cloudfront":               regexp.MustCompile(`(?i)cloudfront`),
+	"server/akamai":                regexp.MustCompile(`(?i)^akamai`),
+	"x-akamai-transformed/akamai":  regexp.MustCompile(`.`),
+	"akamai-grn/akamai":            regexp.MustCompile(`.`),
+	"set-cookie/akamai":            regexp.MustCompile(`^ak_bmsc=|^bm_sz=`),
+	"x-iinfo/imperva":              regexp.MustCompile(`.`),
+	"x-cdn/imperva":                regexp.MustCompile(`(?i)incapsula|imperva`),
+	"set-cookie/imperva":           regexp.MustCompile(`^incap_ses_|^visid_incap_`),
+	"x-sucuri-id/sucuri":           regexp.MustCompile(`.`),
+	"server/sucuri":                regexp.MustCompile(`(?i)sucuri`),
+	"x-azure-ref/azure-front-door": regexp.MustCompile(`.`),
+	"x-served-by/fastly":           regexp.MustCompile(`^cache-`),
+	"server/ddos-guard":            regexp.MustCompile(`(?i)ddos-guard`),
+	"server/f5-bigip":              regexp.MustCompile(`(?i)^big-?ip`),
+	"set-cookie/f5-bigip":          regexp.MustCompile(`^BIGipServer`),
+}
+
+// wafProbe is the one attack-looking request WAF sends: a textbook SQL
+// injection in a query parameter nothing reads.
+const wafProbe = "ceartax=1' OR '1'='1"
+
+// WAF looks for a WAF or CDN in front of the target: vendor headers and
+// cookies on a normal GET /, then a single wafProbe request. A probe
+// that is refused (403, 406, 429, 501...) while / was not means something
+// filters requests even if no vendor is known. The verdict is always
+// written to TechStack["waf"], "none detected" included.
+func (c *Ceartax) WAF(ctx context.Context) error {
+	defer c.moduleDone()
+	defer func() { c.chProg <- progressMsg{module: "waf", value: 1.0} }()
+	base, err := c.fetchPage(ctx, "GET", c.targetURL(""), "")
+	if err != nil {
+		return allFailed(ctx)
+	}
+	seen := make(map[string]bool)
+	var vendors []string
+	for k, re := range wafSignatures {
+		header, vendor, _ := strings.Cut(k, "/")
+		if seen[vendor] {
+			continue
+		}
+		for _, v := range base.Header.Values(header) {
+			if re.MatchString(v) {
+				seen[vendor] = true
+				vendors = append(vendors, vendor)
+				break
+			}
+		}
+	}
+	sort.Strings(vendors)
+	c.chProg <- progressMsg{module: "waf", value: 0.5}
+
+	blocked := 0
+	if ctx.Err() == nil {
+		k, v, _ := strings.Cut(wafProbe, "=")
+		if p, err := c.fetchPage(ctx, "GET", c.targetURL("?"+k+"="+url.QueryEscape(v)), ""); err == nil {
+			switch p.Status {
+			case http.StatusForbidden, http.StatusNotAcceptable, http.StatusTooManyRequests, http.StatusNotImplemented, 419:
+				if p.Status != base.Status {
+					blocked = p.Status
+				}
+			}
+		}
+	}
+
+	verdict := "none detected"
+	switch {
+	case len(vendors) > 0 && blocked != 0:
+		verdict = fmt.Sprintf("%s (blocks probe: %d)", strings.Join(vendors, ", "), blocked)
+	case len(vendors) > 0:
+		verdict = strings.Join(vendors, ", ")
+	case blocked != 0:
+		verdict = fmt.Sprintf("unknown (blocks probe: %d)", blocked)
+	}
+	c.mu.Lock()
+	c.result.TechStack["waf"] = verdict
+	c.mu.Unlock()
+	if verdict != "none detected" {
+		c.emit(ctx, "waf", verdict)
+	}
+	return nil
+}
+
+// inspectRaw sends GET / by hand over the client's dialer and records
+// protocol anomalies in the reply head. Host, port and scheme come from
+// targetURL, so it probes what the other HTTP modules probe.
+func (c *Ceartax) inspectRaw(ctx context.Context) {
+	u, err := url.Parse(c.targetURL(""))
+	if err != nil {
+		c.log.Warn("bad target URL", "err", err)
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	c.countRequest(ctx)
+	defer addBusy(ctx, time.Now())
+	conn, err := c.dial(ctx, net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		c.log.Info("raw dial", "host", u.Host, "err", err)
+		return
+	}
+	if u.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: u.Hostname(), NextProtos: []string{"http/1.1"}})
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	h := http.Header{"User-Agent": {c.randomUA()}}
+	c.applyHeaders(h)
+	h.Del("Host")
+	h.Del("Connection")
+	var reqBuf bytes.Buffer
+	fmt.Fprintf(&reqBuf, "GET %s HTTP/1.1\r\nHost: %s\r\n", u.EscapedPath(), u.Host)
+	h.Write(&reqBuf)
+	reqBuf.WriteString("Connection: close\r\n\r\n")
+	if _, err := conn.Write(reqBuf.Bytes()); err != nil {
+		c.log.Info("raw request", "host", u.Host, "err", err)
+		countError(ctx)
+		return
+	}
+	head, err := readHead(conn, 64<<10)
+	if err != nil && len(head) == 0 {
+		countError(ctx)
+		return
+	}
+	anomalies := headerAnomalies(head)
+	c.mu.Lock()
+	c.result.HeaderAnomalies = anomalies
+	c.mu.Unlock()
+	for _, a := range anomalies {
+		c.emit(ctx, "header-anomaly", a)
+	}
+}
+
+// dial connects the way the HTTP client does (SOCKS proxy, -4/-6), for
+// modules that need the raw connection. An HTTP proxy only carries HTTP,
+// so raw connections go direct in that case.
+func (c *Ceartax) dial(ctx context.Context, addr string) (net.Conn, error) {
+	tr, _ := c.client.Transport.(*http.Transport)
+	if rot, ok := c.client.Transport.(*proxyRotator); ok {
+		tr = rot.transports[rot.pick(nil)]
+	}
+	if tr != nil && tr.DialContext != nil {
+		return tr.DialContext(ctx, c.network, addr)
+	}
+	d := net.Dialer{Timeout: c.timeout}
+	return d.DialContext(ctx, c.network, addr)
+}
+
+// TLS handshakes with the target on 443 and records what was negotiated
+// and the leaf certificate. Trust is recorded by verifyReport as for
+// every other handshake.
+func (c *Ceartax) TLS(ctx context.Context) error {
+	defer c.moduleDone()
+	defer func() { c.chProg <- progressMsg{module: "tls", value: 1.0} }()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	c.countRequest(ctx)
+	defer addBusy(ctx, time.Now())
+	raw, err := c.dial(ctx, net.JoinHostPort(c.target, "443"))
+	if err != nil {
+		c.tlsError(ctx, err)
+		return nil
+	}
+	conn := tls.Client(raw, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         c.target,
+		VerifyConnection:   c.verifyReport,
+	})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		c.tlsError(ctx, err)
+		return nil
+	}
+	cs := conn.ConnectionState()
+	info := map[string]string{
+		"version": tls.VersionName(cs.Version),
+		"cipher":  tls.CipherSuiteName(cs.CipherSuite),
+	}
+	if cs.NegotiatedProtocol != "" {
+		info["alpn"] = cs.NegotiatedProtocol
+	}
+	if len(cs.PeerCertificates) > 0 {
+		leaf := cs.PeerCertificates[0]
+		info["subject"] = leaf.Subject.String()
+		info["issuer"] = leaf.Issuer.String()
+		info["not_before"] = leaf.NotBefore.UTC().Format(time.RFC3339)
+		info["not_after"] = leaf.NotAfter.UTC().Format(time.RFC3339)
+		sans := slices.Clone(leaf.DNSNames)
+		for _, ip := range leaf.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		info["sans"] = strings.Join(sans, ", ")
+	}
+	c.mu.Lock()
+	maps.Copy(c.result.TLSInfo, info)
+	c.mu.Unlock()
+	return nil
+}
+
+// tlsError notes why there is no TLS info; a closed 443 is an answer, not
+// a module failure.
+func (c *Ceartax) tlsError(ctx context.Context, err error) {
+	if ctx.Err() == nil {
+		countError(ctx)
+	}
+	c.mu.Lock()
+	c.result.TLSInfo["error"] = err.Error()
+	c.mu.Unlock()
+}
+
+// readHead reads up to the blank line ending the header block, or limit.
+func readHead(r io.Reader, limit int) ([]byte, error) {
+	var buf []byte
+	chunk := make([]byte, 4096)
+	for len(buf) < limit {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if i := bytes.Index(buf, []byte("\r\n\r\n")); i >= 0 {
+			return buf[:i+2], nil
+		}
+		if i := bytes.Index(buf, []byte("\n\n")); i >= 0 {
+			return buf[:i+1], nil
+		}
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// singleHeaders may appear only once; a repeat (worse, with a different
+// value) lets front end and back end disagree about the message.
+var singleHeaders = []string{"content-length", "location", "content-type", "transfer-encoding", "host"}
+
+// headerAnomalies lists what a strict parser would object to in a reply
+// head: bare LF line endings, no reason phrase, obs-fold, junk in header
+// names, repeated single-valued headers and Content-Length alongside
+// Transfer-Encoding.
+func headerAnomalies(head []byte) []string {
+	var out []string
+	if bytes.Contains(bytes.ReplaceAll(head, []byte("\r\n"), nil), []byte("\n")) {
+		out = append(out, "bare LF line endings")
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(head), "\r\n", "\n"), "\n"), "\n")
+	if len(lines) == 0 {
+		return out
+	}
+	status := strings.SplitN(lines[0], " ", 3)
+	switch {
+	case len(status) < 2 || !strings.HasPrefix(status[0], "HTTP/"):
+		out = append(out, fmt.Sprintf("malformed status line %q", lines[0]))
+	case len(status) == 2 || strings.TrimSpace(status[2]) == "":
+		out = append(out, "status line without reason phrase")
+	}
+	values := map[string][]string{}
+	for _, l := range lines[1:] {
+		if l != "" && (l[0] == ' ' || l[0] == '\t') {
+			out = append(out, "obsolete line folding")
+			continue
+		}
+		name, v, ok := strings.Cut(l, ":")
+		switch {
+		case !ok:
+			out = append(out, fmt.Sprintf("header line without colon %q", l))
+			continue
+		case name != strings.TrimRight(name, " \t"):
+			out = append(out, fmt.Sprintf("whitespace before colon in %q", name))
+		case strings.ContainsAny(name, "()<>@,;\\\"/[]?={} \t"):
+			out = append(out, fmt.Sprintf("invalid header name %q", name))
+		}
+		k := strings.ToLower(strings.TrimSpace(name))
+		values[k] = append(values[k], strings.TrimSpace(v))
+	}
+	for _, h := range singleHeaders {
+		vs := values[h]
+		if len(vs) < 2 {
+			continue
+		}
+		distinct := slices.Clone(vs)
+		slices.Sort(distinct)
+		if len(slices.Compact(distinct)) > 1 {
+			out = append(out, fmt.Sprintf("conflicting %s headers: %s", h, strings.Join(vs, " | ")))
+		} else {
+			out = append(out, fmt.Sprintf("duplicate %s header", h))
+		}
+	}
+	if len(values["content-length"]) > 0 && len(values["transfer-encoding"]) > 0 {
+		out = append(out, "Content-Length together with Transfer-Encoding")
+	}
+	return out
+}
+
+// LBVerdict is the -lb-detect result: backend-identifying response fields
+// that changed across identical requests.
+type LBVerdict struct {
+	Samples    int      `json:"samples"`
+	Likely     bool     `json:"likely"`
+	Confidence string   `json:"confidence"`
+	Evidence   []string `json:"evidence,omitempty"`
+}
+
+// detectLB repeats GET / and looks for values that a single backend would
+// keep stable. Date is compared by clock skew, not equality.
+func (c *Ceartax) detectLB(ctx context.Context) {
+	seen := map[string]map[string]bool{}
+	var offsets []time.Duration
+	v := LBVerdict{}
+	for i := 0; i < c.lbSamples && ctx.Err() == nil; i++ {
+		p, err := c.fetchPage(ctx, "GET", c.targetURL(""), "")
+		if err != nil {
+			continue
+		}
+		v.Samples++
+		fields := map[string]string{
+			"server":      p.Header.Get("Server"),
+			"etag":        p.Header.Get("ETag"),
+			"set-cookie":  cookieNames(p.Header.Values("Set-Cookie")),
+			"x-served-by": p.Header.Get("X-Served-By") + p.Header.Get("X-Backend-Server"),
+			"body":        p.Hash()[:16],
+		}
+		for k, val := range fields {
+			if seen[k] == nil {
+				seen[k] = map[string]bool{}
+			}
+			seen[k][val] = true
+		}
+		if d, err := http.ParseTime(p.Header.Get("Date")); err == nil {
+			offsets = append(offsets, time.Until(d))
+		}
+	}
+	for _, k := range []string{"server", "x-served-by", "etag", "set-cookie", "body"} {
+		if n := len(seen[k]); n > 1 {
+			v.Evidence = append(v.Evidence, fmt.Sprintf("%s: %d distinct values", k, n))
+		}
+	}
+	if skew := clockSkew(offsets); skew >= 2*time.Second {
+		v.Evidence = append(v.Evidence, fmt.Sprintf("date: %s skew between responses", skew))
+	}
+	v.Likely = len(v.Evidence) > 0
+	v.Confidence = confidence(len(v.Evidence))
+	c.mu.Lock()
+	c.result.LoadBalancer = &v
+	c.mu.Unlock()
+}
+
+// confidence grades an inferred finding by how many independent signals
+// support it. Every heuristic detector reports through this so the
+// levels mean the same thing across the report.
+func confidence(signals int) string {
+	switch {
+	case signals >= 3:
+		return "high"
+	case signals == 2:
+		return "medium"
+	case signals == 1:
+		return "low"
+	}
+	return "none"
+}
+
+// cookieNames keeps only the cookie names; values churn on every request.
+func cookieNames(cookies []string) string {
+	var names []string
+	for _, ck := range cookies {
+		name, _, _ := strings.Cut(ck, "=")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// clockSkew is the spread of server-clock offsets; one backend keeps its
+// offset to our clock within Date's one-second resolution.
+func clockSkew(offsets []time.Duration) time.Duration {
+	if len(offsets) < 2 {
+		return 0
+	}
+	return slices.Max(offsets) - slices.Min(offsets)
+}
+
+// fuzzMethods are sent to the root after GET when -method-fuzz is on.
+// FOOBAR is deliberately invalid to see how unknown verbs are routed.
+var fuzzMethods = []string{"OPTIONS", "TRACE", "PUT", "FOOBAR"}
+
+// MethodProbe is the root's answer to one HTTP method.
+type MethodProbe struct {
+	Method    string `json:"method"`
+	Status    int    `json:"status"`
+	Length    int64  `json:"length"`
+	LatencyMs int64  `json:"latency_ms"`
+	Differs   bool   `json:"differs"` // status or body differs from GET
+	Error     string `json:"error,omitempty"`
+	hash      string
+}
+
+func (c *Ceartax) probeMethod(ctx context.Context, method string) MethodProbe {
+	mp := MethodProbe{Method: method}
+	start := time.Now()
+	p, err := c.fetchPage(ctx, method, c.targetURL(""), "")
+	if err != nil {
+		mp.Error = err.Error()
+		return mp
+	}
+	mp.LatencyMs = time.Since(start).Milliseconds()
+	mp.Status = p.Status
+	mp.Length = int64(len(p.Body))
+	mp.hash = p.Hash()
+	return mp
+}
+
+// fuzzMethods compares a few uncommon methods against GET to expose
+// method-based routing or WAF rules.
+func (c *Ceartax) fuzzMethods(ctx context.Context) {
+	base := c.probeMethod(ctx, "GET")
+	probes := []MethodProbe{base}
+	for _, m := range fuzzMethods {
+		mp := c.probeMethod(ctx, m)
+		mp.Differs = mp.Error == "" && base.Error == "" && (mp.Status != base.Status || mp.hash != base.hash)
+		probes = append(probes, mp)
+	}
+	c.mu.Lock()
+	c.result.MethodProbes = probes
+	c.mu.Unlock()
+}
+
+// cleanValue makes bytes taken off the wire safe for JSON, HTML and the
+// terminal: invalid UTF-8 and control characters become \xNN. With
+// -raw-bytes such values are kept intact as "b64:<base64>" instead.
+func (c *Ceartax) cleanValue(v string) string {
+	bad := func(r rune, size int) bool {
+		return (r == utf8.RuneError && size == 1) || (unicode.IsControl(r) && r != '\t')
+	}
+	clean := true
+	for i := 0; i < len(v) && clean; {
+		r, size := utf8.DecodeRuneInString(v[i:])
+		clean = !bad(r, size)
+		i += size
+	}
+	if clean {
+		return v
+	}
+	if c.rawBytes {
+		return "b64:" + base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	var b strings.Builder
+	for i := 0; i < len(v); {
+		r, size := utf8.DecodeRuneInString(v[i:])
+		if bad(r, size) {
+			for _, x := range []byte(v[i : i+size]) {
+				fmt.Fprintf(&b, "\\x%02x", x)
+			}
+		} else {
+			b.WriteString(v[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// nonHTTPService tells "something answered, but not HTTPS" apart from
+// "nothing there". Refused and timed-out connections return "".
+func nonHTTPService(err error) string {
+	var rh tls.RecordHeaderError
+	switch {
+	case strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"):
+		return "plain HTTP on TLS port"
+	case errors.As(err, &rh):
+		return "non-HTTP service detected (not TLS)"
+	case strings.Contains(err.Error(), "malformed HTTP"):
+		return "non-HTTP service detected (TLS, not HTTP)"
+	}
+	return ""
+}
+
+// robotsRule is one Disallow line for User-agent *. Paths may use the
+// usual * wildcard and $ end anchor.
+type robotsRule struct {
+	path string
+	re   *regexp.Regexp
+}
+
+// robotsRules fetches /robots.txt once per scan; whichever module asks
+// first pays for the request. Only 404 and 410 mean there are no rules.
+// Any other failure is an error, since a robots.txt we could not read
+// may disallow anything. A fetch cut short by the caller's own context
+// is not kept, so the next caller tries again with its own.
+func (c *Ceartax) robotsRules(ctx context.Context) ([]robotsRule, error) {
+	c.robotsMu.Lock()
+	defer c.robotsMu.Unlock()
+	if c.robotsDone {
+		return c.robots, c.robotsErr
+	}
+	p, err := c.fetchPage(ctx, "GET", "https://"+c.target+"/robots.txt", "")
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	c.robotsDone = true
+	switch {
+	case err != nil:
+		c.robotsErr = fmt.Errorf("robots.txt: %w", err)
+	case p.Status == http.StatusOK:
+		c.robots = parseRobots(string(p.Body))
+	case p.Status != http.StatusNotFound && p.Status != http.StatusGone:
+		c.robotsErr = fmt.Errorf("robots.txt: HTTP %d", p.Status)
+	}
+	return c.robots, c.robotsErr
+}
+
+// parseRobots keeps the Disallow rules of the groups that name
+// User-agent *. An empty Disallow allows everything and is dropped.
+func parseRobots(body string) []robotsRule {
+	var rules []robotsRule
+	inGroup, forAll := false, false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if !inGroup {
+				forAll = false
+			}
+			inGroup = true
+			if val == "*" {
+				forAll = true
+			}
+		case "disallow":
+			inGroup = false
+			if forAll && val != "" {
+				pat := "^" + strings.ReplaceAll(regexp.QuoteMeta(val), `\*`, ".*")
+				if strings.HasSuffix(val, "$") {
+					pat = strings.TrimSuffix(pat, `\$`) + "$"
+				}
+				if re, err := regexp.Compile(pat); err == nil {
+					rules = append(rules, robotsRule{path: val, re: re})
+				}
+			}
+		default:
+			inGroup = false
+		}
+	}
+	return rules
+}
+
+func robotsDisallowed(rules []robotsRule, path string) bool {
+	for _, r := range rules {
+		if r.re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirPaths are the Directories candidates, relative to -base-path.
+var dirPaths = []string{".git", "robots.txt", "admin"}
+
+// dirMatch reports whether a probed path with this status is a finding.
+func (c *Ceartax) dirMatch(status int) bool {
+	if c.matchCodes == nil {
+		return status < 400
+	}
+	return c.matchCodes[status]
+}
+
+// dirCandidates is dirPaths, each entry followed by itself with every
+// -extensions suffix (admin, admin.php, admin.bak), without duplicates.
+func (c *Ceartax) dirCandidates() []string {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	for _, d := range dirPaths {
+		add(d)
+		if strings.HasSuffix(d, "/") {
+			continue
+		}
+		for _, ext := range c.extensions {
+			add(d + "." + ext)
+		}
+	}
+	return out
+}
+
+// dirRequestCap bounds a -recurse run. A catch-all that redirects every
+// path to itself plus a slash would otherwise recurse until the depth
+// limit with the full list at every level.
+const dirRequestCap = 20000
+
+type dirJob struct {
+	path  string
+	depth int // 1 for the candidates at -base-path
+}
+
+// dirQueue is the Directories work queue. With -recurse it grows while
+// the workers drain it, so it is a guarded slice instead of a channel
+// seeded once. pending counts queued plus in-flight jobs: pop blocks
+// while more work may still be pushed and reports false once none can
+// be, or once the queue is closed.
+type dirQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    []dirJob
+	pending int
+	closed  bool
+}
+
+func newDirQueue() *dirQueue {
+	q := &dirQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *dirQueue) push(j dirJob) {
+	q.mu.Lock()
+	q.jobs = append(q.jobs, j)
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+func (q *dirQueue) pop() (dirJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 && q.pending > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed || len(q.jobs) == 0 {
+		return dirJob{}, false
+	}
+	j := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	return j, true
+}
+
+// done finishes a popped job; push its follow-ups before calling it.
+func (q *dirQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	last := q.pending == 0
+	q.mu.Unlock()
+	if last {
+		q.cond.Broadcast()
+	}
+}
+
+func (q *dirQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// Dirs probes dirCandidates under -base-path. With -recurse every
+// candidate that turns out to be a directory gets the whole list again
+// beneath it, up to -dir-recurse-depth levels; paths already
+// queued are never queued again. With -respect-robots, paths robots.txt
+// disallows for * are not requested, and a robots.txt that cannot be
+// read fails the module before anything is probed.
+func (c *Ceartax) Dirs(ctx context.Context) error {
+	defer c.moduleDone()
+	base := c.dirCandidates()
+	var rules []robotsRule
+	if c.respectRobots {
+		var err error
+		if rules, err = c.robotsRules(ctx); err != nil {
+			// Unknown rules could disallow any candidate: probe none.
+			c.chProg <- progressMsg{module: "dirs", value: 1.0}
+			return fmt.Errorf("-respect-robots: %w", err)
+		}
+	}
+	levels := 1
+	if c.dirRecurse {
+		levels = c.dirDepth
+	}
+	q := newDirQueue()
+	stop := context.AfterFunc(ctx, q.close)
+	defer stop()
+	var seenMu sync.Mutex
+	seen := make(map[string]bool)
+	queued, capped := 0, false
+	enqueue := func(prefix string, depth int) {
+		seenMu.Lock()
+		defer seenMu.Unlock()
+		for _, d := range base {
+			p := prefix + d
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if robotsDisallowed(rules, c.basePath+p) {
+				c.mu.Lock()
+				c.result.RobotsSkipped = append(c.result.RobotsSkipped, c.basePath+p)
+				c.mu.Unlock()
+				continue
+			}
+			if queued >= dirRequestCap {
+				if !capped {
+					capped = true
+					c.logf("Directories: %d paths queued, not recursing further", queued)
+				}
+				return
+			}
+			queued++
+			q.push(dirJob{path: p, depth: depth})
+		}
+	}
+	enqueue("", 1)
+	total := float64(queued)
+	var done atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, ok := q.pop()
+				if !ok {
+					return
+				}
+				if c.probeDir(ctx, j.path) && j.depth < levels {
+					enqueue(strings.TrimSuffix(j.path, "/")+"/", j.depth+1)
+				}
+				q.done()
+				n := done.Add(1)
+				if c.dirRecurse {
+					c.chProg <- progressMsg{module: "dirs", value: -1, done: int(n)}
+				} else {
+					c.chProg <- progressMsg{module: "dirs", value: float64(n) / total}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	c.chProg <- progressMsg{module: "dirs", value: 1.0}
+	return allFailed(ctx)
+}
+
+// probeDir requests one candidate and records its status; a status
+// dirMatch accepts makes it a directory finding. -grep needs the bodies,
+// so it turns the HEAD probes into GETs. It reports whether the path is
+// a directory worth recursing into: below 400 and either ending in a
+// slash or redirected to the same path with one.
+func (c *Ceartax) probeDir(ctx context.Context, d string) bool {
+	method := "HEAD"
+	if c.grep != nil {
+		method = "GET"
+	}
+	u := c.targetURL(d)
+	req, err := c.newRequest(ctx, method, u)
+	if err != nil {
+		c.log.Warn("bad directory URL", "url", u, "err", err)
+		return false
+	}
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		c.log.Info("directory probe", "url", u, "err", err)
+		return false
+	}
+	match := c.dirMatch(resp.StatusCode)
+	if c.grep != nil && match {
+		c.grepBody(ctx, u, c.readBody(u, resp))
+	}
+	resp.Body.Close()
+	c.mu.Lock()
+	c.result.DirStatuses[u] = resp.StatusCode
+	if match {
+		c.result.Directories = append(c.result.Directories, u)
+	}
+	c.mu.Unlock()
+	if match {
+		c.emit(ctx, "dir", u)
+	}
+	if resp.StatusCode >= 400 {
+		return false
+	}
+	return strings.HasSuffix(d, "/") || resp.Request.URL.Path == strings.TrimSuffix(req.URL.Path, "/")+"/"
+}
+
+// === SCREENSHOTS ===
+// Screenshot is a PNG of one web service, taken with -screenshots.
+type Screenshot struct {
+	URL  string `json:"url"`
+	File string `json:"file"`
+}
+
+const (
+	shotTimeout = 30 * time.Second // per page, browser start included
+	shotWorkers = 4                // browsers at once, also bounded by -concurrency
+	maxShots    = 200
+)
+
+// chromeNames are looked up on PATH when -chrome-path is not given.
+var chromeNames = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "headless_shell"}
+
+// findChrome returns the browser -screenshots drives: -chrome-path if set,
+// otherwise the first of chromeNames on PATH.
+func findChrome(path string) (string, error) {
+	if path != "" {
+		return exec.LookPath(path)
+	}
+	for _, n := range chromeNames {
+		if p, err := exec.LookPath(n); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("none of %s on PATH", strings.Join(chromeNames, ", "))
+}
+
+// hostSources are the modules whose results Screenshots photographs; it
+// waits for all of them to finish before it starts.
+var hostSources = map[string]bool{"Subdomains": true, "CrtSh": true, "Ports": true}
+
+// webURLs is what Screenshots visits: the target on every open web port
+// (or targetURL alone when no ports were scanned) and every live
+// subdomain over https.
+func (c *Ceartax) webURLs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []string
+	for _, p := range c.result.OpenPorts {
+		switch {
+		case p == 443 || p == 8443:
+			out = append(out, "https://"+net.JoinHostPort(c.target, strconv.Itoa(p))+"/")
+		case httpPorts[p]:
+			out = append(out, "http://"+net.JoinHostPort(c.target, strconv.Itoa(p))+"/")
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, c.targetURL(""))
+	}
+	for _, h := range c.result.LiveSubdomains {
+		out = append(out, "https://"+h+"/")
+	}
+	return out
+}
+
+// Screenshots saves a PNG of every web service the scan found into
+// <output>/screenshots, using a headless Chrome or Chromium per page.
+func (c *Ceartax) Screenshots(ctx context.Context) error {
+	defer c.moduleDone()
+	defer func() { c.chProg <- progressMsg{module: "shot", value: 1.0} }()
+	ready := make(chan struct{})
+	go func() {
+		c.hostsReady.Wait()
+		close(ready)
+	}()
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		return nil
+	}
+	urls := c.webURLs()
+	if len(urls) > maxShots {
+		c.logf("Screenshots: %d web services, taking the first %d", len(urls), maxShots)
+		urls = urls[:maxShots]
+	}
+	dir := c.outPath("screenshots")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, u := range urls {
+			select {
+			case ch <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var done atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.concurrency, shotWorkers, len(urls)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range ch {
+				file := filepath.Join(dir, safeName(u)+".png")
+				if err := c.screenshot(ctx, u, file); err != nil {
+					countError(ctx)
+					c.log.Info("screenshot", "url", u, "err", err)
+				} else {
+					c.mu.Lock()
+					c.result.Screenshots = append(c.result.Screenshots, Screenshot{URL: u, File: file})
+					c.mu.Unlock()
+					c.emit(ctx, "screenshot", file)
+				}
+				n := done.Add(1)
+				c.chProg <- progressMsg{module: "shot", value: float64(n) / float64(len(urls))}
+			}
+		}()
+	}
+	wg.Wait()
+	return allFailed(ctx)
+}
+
+// screenshot runs the browser once for u. Certificate errors are ignored
+// as in the HTTP client; the proxy, if any, is handed on without its
+// credentials, which Chrome does not take and argv would expose to
+// every local user. The sandbox is only dropped when running as root,
+// where Chrome refuses to start with it.
+func (c *Ceartax) screenshot(ctx context.Context, u, file string) error {
+	c.countRequest(ctx)
+	defer addBusy(ctx, time.Now())
+	ctx, cancel := context.WithTimeout(ctx, shotTimeout)
+	defer cancel()
+	args := []string{
+		"--headless=new", "--disable-gpu", "--hide-scrollbars", "--no-first-run",
+		"--ignore-certificate-errors", "--window-size=1280,800",
+		"--user-agent=" + c.randomUA(), "--screenshot=" + file,
+	}
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	proxy := c.proxyURL
+	if len(c.proxies) > 0 {
+		proxy = c.proxies[rand.Intn(len(c.proxies))]
+	}
+	if proxy != "" {
+		p, err := url.Parse(proxy)
+		if err != nil {
+			return errors.New("proxy URL does not parse") // err would echo the credentials
+		}
+		p.User = nil
+		args = append(args, "--proxy-server="+p.String())
+	}
+	out, err := exec.CommandContext(ctx, c.chromePath, append(args, u)...).CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+	}
+	if _, err := os.Stat(file); err != nil {
+		return errors.New("browser exited without writing a screenshot")
+	}
+	return nil
+}
+
+// APISpec is API documentation found on the target.
+type APISpec struct {
+	URL     string `json:"url"`
+	Kind    string `json:"kind"` // openapi, swagger, swagger-ui, graphql
+	Version string `json:"version,omitempty"`
+}
+
+// Endpoint is one operation defined in a discovered spec.
+type Endpoint struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Spec   string `json:"spec"` // URL of the spec that defines it
+}
+
+// apiSpecPaths are probed relative to -base-path. graphql is asked for
+// __typename so only a real GraphQL endpoint answers with data.
+var apiSpecPaths = []string{
+	"swagger.json", "openapi.json", "swagger.yaml", "openapi.yaml",
+	"api-docs", "v2/api-docs", "v3/api-docs", "swagger-ui/",
+	".well-known/openapi.json", ".well-known/openapi.yaml",
+	"graphql?query=%7B__typename%7D",
+}
+
+var specMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// APISpecs looks for API documentation. A 200 alone proves nothing (many
+// frameworks answer everything), so each hit must parse as what it
+// claims to be before it is recorded.
+func (c *Ceartax) APISpecs(ctx context.Context) error {
+	defer c.moduleDone()
+	seen := make(map[string]bool)
+	for i, path := range apiSpecPaths {
+		if ctx.Err() != nil {
+			break
+		}
+		c.randomDelay(ctx)
+		u := c.targetURL(path)
+		p, err := c.fetchPage(ctx, "GET", u, "")
+		c.chProg <- progressMsg{module: "api", value: float64(i+1) / float64(len(apiSpecPaths))}
+		if err != nil || p.Status >= 400 {
+			continue
+		}
+		spec := APISpec{URL: u}
+		var eps []Endpoint
+		switch {
+		case strings.HasPrefix(path, "graphql"):
+			var gq struct {
+				Data map[string]any `json:"data"`
+			}
+			if json.Unmarshal(p.Body, &gq) != nil || gq.Data["__typename"] == nil {
+				continue
+			}
+			spec.URL, spec.Kind = c.targetURL("graphql"), "graphql"
+		case strings.HasPrefix(path, "swagger-ui"):
+			if !bytes.Contains(p.Body, []byte("swagger-ui")) {
+				continue
+			}
+			spec.Kind = "swagger-ui"
+		default:
+			var ok bool
+			if spec.Kind, spec.Version, eps, ok = parseSpec(p.Body, u); !ok {
+				continue
+			}
+		}
+		c.mu.Lock()
+		c.result.APISpecs = append(c.result.APISpecs, spec)
+		for _, e := range eps {
+			if k := e.Method + " " + e.Path; !seen[k] {
+				seen[k] = true
+				c.result.Endpoints = append(c.result.Endpoints, e)
+			}
+		}
+		c.mu.Unlock()
+		c.emit(ctx, "api-spec", spec.URL)
+	}
+	return allFailed(ctx)
+}
+
+// parseSpec accepts a Swagger 2 or OpenAPI 3 document in JSON or YAML and
+// lists its operations. Swagger's basePath is prefixed to every path.
+func parseSpec(body []byte, src string) (kind, version string, eps []Endpoint, ok bool) {
+	var doc struct {
+		Swagger  string                    `json:"swagger" yaml:"swagger"`
+		OpenAPI  string                    `json:"openapi" yaml:"openapi"`
+		BasePath string                    `json:"basePath" yaml:"basePath"`
+		Paths    map[string]map[string]any `json:"paths" yaml:"paths"`
+	}
+	if json.Unmarshal(body, &doc) != nil && yaml.Unmarshal(body, &doc) != nil {
+		return "", "", nil, false
+	}
+	switch {
+	case doc.OpenAPI != "":
+		kind, version = "openapi", doc.OpenAPI
+	case doc.Swagger != "":
+		kind, version = "swagger", doc.Swagger
+	default:
+		return "", "", nil, false
+	}
+	if doc.Paths == nil {
+		return "", "", nil, false
+	}
+	base := strings.TrimSuffix(doc.BasePath, "/")
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		for _, m := range specMethods {
+			if _, ok := doc.Paths[p][m]; ok {
+				eps = append(eps, Endpoint{Method: strings.ToUpper(m), Path: base + p, Spec: src})
+			}
+		}
+	}
+	return kind, version, eps, true
+}
+
+func (c *Ceartax) moduleDone() { c.chDone <- doneMsg{} }
+
+// modules is every scan module in scheduling order. -modules selects
+// among them by lowercased name; discovery modules send nothing to the
+// hosts they find and are the only ones -list-only runs.
+var modules = []struct {
+	name      string
+	fn        func(*Ceartax, context.Context) error
+	discovery bool
+	optIn     bool // only runs when its own flag enabled it
+}{
+	{"Subdomains", (*Ceartax).Subdomains, true, false},
+	{"CrtSh", (*Ceartax).CrtSh, true, false},
+	{"DNS", (*Ceartax).DNS, false, false},
+	{"Ports", (*Ceartax).Ports, false, false},
+	{"Fingerprint", (*Ceartax).Fingerprint, false, false},
+	{"Favicon", (*Ceartax).Favicon, false, false},
+	{"WAF", (*Ceartax).WAF, false, false},
+	{"TLS", (*Ceartax).TLS, false, false},
+	{"Directories", (*Ceartax).Dirs, false, false},
+	{"APISpecs", (*Ceartax).APISpecs, false, false},
+	{"Screenshots", (*Ceartax).Screenshots, false, true},
+}
+
+// moduleNames lists the names -modules accepts.
+func moduleNames() []string {
+	var out []string
+	for _, m := range modules {
+		out = append(out, strings.ToLower(m.name))
+	}
+	return out
+}
+
+// selected reports whether this scan runs module name. Screenshots is
+// the only opt-in module so far; -screenshots with a browser enables it.
+func (c *Ceartax) selected(name string, discovery, optIn bool) bool {
+	if optIn && c.chromePath == "" {
+		return false
+	}
+	return !(c.listOnly && !discovery || c.modules != nil && !c.modules[strings.ToLower(name)])
+}
+
+// Run schedules the selected modules; see modules.
+func (c *Ceartax) Run() {
+	for _, m := range modules {
+		if !c.selected(m.name, m.discovery, m.optIn) {
+			continue
+		}
+		fn := m.fn
+		if hostSources[m.name] {
+			c.hostsReady.Add(1)
+			c.runBench(m.name, func(ctx context.Context) error {
+				defer c.hostsReady.Done()
+				return fn(c, ctx)
+			})
+			continue
+		}
+		c.runBench(m.name, func(ctx context.Context) error { return fn(c, ctx) })
+	}
+	go func() {
+		err := c.pool.Wait()
+		c.mu.Lock()
+		c.runErr = err
+		c.mu.Unlock()
+		// Nobody may read the last doneMsg once the scan is cancelled or
+		// a headless run has its benchmarks; don't block on it then.
+		select {
+		case c.chDone <- doneMsg{}:
+		case <-c.ctx.Done():
+		}
+	}()
+}
+
+// Rerun starts module name again on the finished scan, after clearing
+// what its last run put in the result. It reports false for a module
+// this scan does not run. The new benchmark arrives on chBench as usual;
+// the caller drops the old one.
+func (c *Ceartax) Rerun(name string) bool {
+	for _, m := range modules {
+		if m.name != name || !c.selected(m.name, m.discovery, m.optIn) {
+			continue
+		}
+		c.resetModule(name)
+		fn := m.fn
+		c.startModule(name, 0, func(ctx context.Context) error { return fn(c, ctx) })
+		return true
+	}
+	return false
+}
+
+// resetModule clears the result fields only module name fills. Shared
+// ones (subdomains, tech stack, TLS info) are deduplicated or
+// overwritten on their own; findings are events and stay.
+func (c *Ceartax) resetModule(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := &c.result
+	delete(r.Aborted, name)
+	delete(r.Failed, name)
+	switch name {
+	case "DNS":
+		r.DNSRecords = nil
+	case "Ports":
+		r.OpenPorts, r.HostPorts, r.ClosedMs = nil, nil, nil
+		r.ConnectMs = make(map[int]float64)
+		r.Banners = make(map[int]string)
+	case "Fingerprint":
+		r.Headers = make(map[string][]string)
+		r.RedirectChain, r.MethodProbes, r.LoadBalancer, r.HeaderAnomalies, r.RobotsDisallow = nil, nil, nil, nil, nil
+		r.HTTPVersions = nil
+	case "Directories":
+		r.Directories, r.RobotsSkipped = nil, nil
+		r.DirStatuses = make(map[string]int)
+	case "APISpecs":
+		r.APISpecs, r.Endpoints = nil, nil
+	case "Screenshots":
+		r.Screenshots = nil
+	}
+}
+
+// Interrupt stops the scan early, as Ctrl+C or SIGINT do: the result is
+// saved as interrupted and a sweep does not go on to the next target.
+// Cancelling also releases anything blocked by a pause.
+func (c *Ceartax) Interrupt() {
+	c.interrupted.Store(true)
+	c.cancel()
+}
+
+// Err is the first module failure of the finished scan; every failure is
+// also in the result's Failed map.
+func (c *Ceartax) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runErr
+}
+
+func (c *Ceartax) stampTotals() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result.TotalRequests = c.totalRequests.Load()
+	c.result.TotalBytes = c.totalBytes.Load()
+	c.result.Status = "complete"
+	if c.interrupted.Load() {
+		c.result.Status = "interrupted"
+	}
+}
+
+// snapshot deep-copies the result under c.mu. Every field is exported and
+// tagged, so a JSON round trip copies the nested maps and slices too.
+func (c *Ceartax) snapshot() (*ReconResult, error) {
+	c.mu.Lock()
+	data, err := json.Marshal(&c.result)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var r ReconResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// benchWorkloadFlags change how much work a module does; -bench-only
+// refuses them so every run measures the same built-in workload.
+var benchWorkloadFlags = map[string]bool{
+	"sub-wordlist": true, "recurse-depth": true, "ports": true, "top-ports": true,
+	"exclude-ports": true, "extensions": true, "recurse": true, "dir-recurse-depth": true,
+}
+
+// RunBenchOnly runs every module headless and returns the benchmarks
+// sorted by module name so runs diff cleanly.
+func (c *Ceartax) RunBenchOnly() []Benchmark {
+	out := c.runHeadless()
+	sort.Slice(out, func(i, j int) bool { return out[i].Module < out[j].Module })
+	return out
+}
+
+// Plan writes what Run would do, module by module, without sending
+// anything: -dry-run prints it instead of scanning.
+func (c *Ceartax) Plan(w io.Writer) {
+	fmt.Fprintf(w, "Target: %s\n", c.target)
+	proxy := "none (direct)"
+	switch {
+	case len(c.proxies) > 0:
+		proxy = fmt.Sprintf("%d rotated per request", len(c.proxies))
+	case c.proxyURL != "":
+		proxy = redactURL(c.proxyURL)
+	}
+	pace := "random 1-2s delay per probe"
+	if c.limiter != nil {
+		pace = fmt.Sprintf("%g requests/s", float64(c.limiter.Limit()))
+	}
+	fmt.Fprintf(w, "  proxy: %s, %d user agents, concurrency %d, %s\n", proxy, len(c.uaList), c.concurrency, pace)
+	for _, m := range modules {
+		if !c.selected(m.name, m.discovery, m.optIn) {
+			continue
+		}
+		fmt.Fprintf(w, "  %-12s %s\n", m.name, c.modulePlan(m.name))
+	}
+	if c.output == stdoutPath {
+		fmt.Fprintln(w, "  reports: JSON on stdout")
+	} else if c.output != "" {
+		jsonPath, htmlPath := c.reportPaths()
+		paths := map[string]string{"json": jsonPath, "html": htmlPath, "txt": c.reportPath(".txt"), "csv": c.reportPath(".csv"), "nmap-xml": c.reportPath(".xml")}
+		var out []string
+		for _, f := range []string{"json", "html", "txt", "csv", "nmap-xml"} {
+			if c.formats[f] {
+				out = append(out, paths[f])
+			}
+		}
+		fmt.Fprintf(w, "  reports: %s\n", strings.Join(out, ", "))
+	}
+}
+
+// modulePlan is one line of Plan: what module name would send where.
+func (c *Ceartax) modulePlan(name string) string {
+	switch name {
+	case "Subdomains":
+		s := fmt.Sprintf("%d words under %s, depth %d (at most %d lookups)", c.subWordTotal(), c.target, c.recurseDepth, subLookupCap)
+		if !c.listOnly {
+			s += ", catch-all probe, alive check per find"
+		}
+		return s
+	case "CrtSh":
+		return "GET https://crt.sh/?q=%." + c.target + ", then resolve each name"
+	case "DNS":
+		via := "system resolver"
+		if c.dns != nil && c.dns.servers[0].addr != "" {
+			var addrs []string
+			for _, s := range c.dns.servers {
+				addrs = append(addrs, s.addr)
+			}
+			via = strings.Join(addrs, ", ")
+		}
+		return fmt.Sprintf("MX, NS, TXT, CNAME, SOA for %s and TXT for _dmarc.%s via %s", c.target, c.target, via)
+	case "Ports":
+		ports := c.portList()
+		if c.rangeHosts != nil {
+			return fmt.Sprintf("%d ports on each of %d addresses in %s (%d dials), %s", len(ports), len(c.rangeHosts), c.target, len(ports)*len(c.rangeHosts), c.network)
+		}
+		return fmt.Sprintf("%d ports on %s, %s, batches of %d, dial timeout %s", len(ports), c.target, c.network, c.portBatch, c.dialTimeout)
+	case "Fingerprint":
+		s := "GET " + c.targetURL("") + " (raw and parsed), HEAD over HTTP/2"
+		if c.respectRobots {
+			s += ", robots.txt"
+		}
+		if c.methodFuzz {
+			s += ", uncommon methods on /"
+		}
+		if c.lbSamples > 1 {
+			s += fmt.Sprintf(", %d LB samples", c.lbSamples)
+		}
+		return s
+	case "Favicon":
+		return "GET https://" + c.target + "/favicon.ico, else the icon " + c.targetURL("") + " links to"
+	case "WAF":
+		return fmt.Sprintf("GET %s, then one request with %q", c.targetURL(""), wafProbe)
+	case "TLS":
+		return "TLS handshake with " + net.JoinHostPort(c.target, "443")
+	case "Directories":
+		method := "HEAD"
+		if c.grep != nil {
+			method = "GET"
+		}
+		s := fmt.Sprintf("%s %d paths under %s", method, len(c.dirCandidates()), c.targetURL(""))
+		if c.dirRecurse {
+			s += fmt.Sprintf(", again under every directory found for %d levels (at most %d requests)", c.dirDepth, dirRequestCap)
+		}
+		return s
+	case "APISpecs":
+		return fmt.Sprintf("GET %d spec paths under %s", len(apiSpecPaths), c.targetURL(""))
+	case "Screenshots":
+		return fmt.Sprintf("%s on every web port and live subdomain found (at most %d), PNGs into %s", c.chromePath, maxShots, c.outPath("screenshots"))
+	}
+	return ""
+}
+
+// redactURL hides the password of a proxy URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// runHeadless runs the scheduled modules without the TUI, draining the
+// progress and done channels, and returns the benchmarks. The scan's
+// context is cancelled on return, which releases Run's goroutine.
+func (c *Ceartax) runHeadless() []Benchmark {
+	defer c.cancel()
+	c.Run()
+	var out []Benchmark
+	for len(out) < c.scheduled {
+		select {
+		case <-c.chProg:
+		case <-c.chDone:
+		case b := <-c.chBench:
+			out = append(out, b.b)
+		}
+	}
+	return out
+}
+
+// RunHeadless is the full scan without the TUI, for CI and scripts.
+// Progress (in 10% steps) and each module's benchmark go to the log as
+// plain lines, then every sink writes the result as saveResults does. It
+// reports whether any module finished with DONE.
+func (c *Ceartax) RunHeadless() bool {
+	c.Run()
+	var bench []Benchmark
+	step := make(map[string]int)
+	for len(bench) < c.scheduled {
+		select {
+		case p := <-c.chProg:
+			if s := int(p.value * 10); s > step[p.module] {
+				step[p.module] = s
+				log.Printf("%s %d%%", p.module, s*10)
+			}
+		case <-c.chDone:
+		case b := <-c.chBench:
+			bench = append(bench, b.b)
+			log.Printf("%s %s in %s (%d requests, %d errors)", b.b.Module, b.b.Status,
+				b.b.Duration.Round(time.Millisecond), b.b.Requests, b.b.Errors)
+		}
+	}
+	// The last benchmark can arrive before Run's goroutine stores the
+	// pool error; Wait again so Err is set when we return.
+	err := c.pool.Wait()
+	c.mu.Lock()
+	c.runErr = err
+	c.mu.Unlock()
+
+	c.stampTotals()
+	for _, s := range c.sinks {
+		if err := s.Write(&c.result, bench); err != nil {
+			log.Printf("output: %v", err)
+		}
+	}
+	for _, b := range bench {
+		if b.Status == "DONE" {
+			return true
+		}
+	}
+	return false
+}
+
+// RunListOnly enumerates hosts and writes one resolvable host per line
+// (with its addresses if withIPs) to w, plus any -split-output files.
+func (c *Ceartax) RunListOnly(w io.Writer, withIPs bool) error {
+	bench := c.runHeadless()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sort.Strings(c.result.Subdomains)
+	for _, h := range c.result.Subdomains {
+		line := h
+		if withIPs {
+			line += " " + strings.Join(c.result.SubdomainIPs[h], ",")
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	for _, s := range c.sinks {
+		if err := s.Write(&c.result, bench); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// === TUI ===
+func (m model) Init() tea.Cmd {
+	m.ceartax.Run()
+	return tea.Batch(
+		m.frameCmd(),
+		m.progressCmd(),
+		m.benchCmd(),
+		m.doneCmd(),
+		m.logCmd(),
+	)
+}
+
+func (m model) frameCmd() tea.Cmd {
+	return tea.Tick(16*time.Millisecond, func(t time.Time) tea.Msg {
+		m.frameCount++
+		if time.Since(m.lastFrame) >= time.Second {
+			m.fps = float64(m.frameCount) / time.Since(m.lastFrame).Seconds()
+			m.frameCount = 0
+			m.lastFrame = time.Now()
+		}
+		select {
+		case m.repaintCh <- struct{}{}:
+		default:
+		}
+		return frameMsg{}
+	})
+}
+
+// progressCmd, benchCmd and doneCmd each wait for one message; Update
+// issues the same command again after handling it, so the channels are
+// read for as long as the scan runs.
+func (m model) progressCmd() tea.Cmd {
+	return func() tea.Msg { return <-m.ceartax.chProg }
+}
+
+func (m model) benchCmd() tea.Cmd {
+	return func() tea.Msg { return <-m.ceartax.chBench }
+}
+
+func (m model) doneCmd() tea.Cmd {
+	return func() tea.Msg { return <-m.ceartax.chDone }
+}
+
+func (m model) logCmd() tea.Cmd {
+	return func() tea.Msg { return <-m.ceartax.chLog }
+}
+
+// finished saves the results once every scheduled module has reported.
+// The last benchmark and the last doneMsg can arrive in either order, so
+// both check.
+func (m *model) finished() bool {
+	if m.ready || len(m.benchmarks) < m.ceartax.scheduled {
+		return m.ready
+	}
+	m.ready = true
+	m.sampleMem()
+	m.saveResults()
+	return true
+}
+
+// finishNow saves the partial result without waiting for the modules
+// that have not reported yet, unless finished already saved it.
+func (m *model) finishNow() {
+	if m.ready {
+		return
+	}
+	m.ready = true
+	m.saveResults()
+}
+
+// rerun handles a rerunKeys key on the results screen: the module's old
+// benchmark and bar go, and finished saves again once it reports back.
+func (m *model) rerun(key string) {
+	for _, k := range rerunKeys {
+		if k.key != key || !m.ceartax.Rerun(k.module) {
+			continue
+		}
+		m.benchmarks = slices.DeleteFunc(m.benchmarks, func(b Benchmark) bool { return b.Module == k.module })
+		delete(m.progress, k.bar)
+		delete(m.rates, k.bar)
+		m.ready = false
+		m.phase = "Re-run: " + k.module
+		return
+	}
+}
+
+// memSampleEvery spaces out ReadMemStats, which stops the world, rather
+// than calling it on every frame.
+const memSampleEvery = 250 * time.Millisecond
+
+// sampleMem keeps the peak heap size for the summary line.
+func (m *model) sampleMem() {
+	m.peakKB = max(m.peakKB, m.ceartax.memKB())
+	m.memAt = time.Now()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case tea.KeyMsg:
+		switch msg.(tea.KeyMsg).String() {
+		case "ctrl+c":
+			// Saved already, or a second Ctrl+C: stop waiting.
+			if m.ready || m.ceartax.interrupted.Load() {
+				m.ceartax.interrupted.Store(true)
+				m.finishNow()
+				return m, tea.Quit
+			}
+			// The modules return what they have and finished saves it,
+			// marked interrupted, as the last of them reports.
+			m.ceartax.Interrupt()
+			m.phase = "Interrupted, saving partial results (Ctrl+C again to quit now)"
+			return m, tea.Tick(interruptGrace, func(time.Time) tea.Msg { return interruptTimeoutMsg{} })
+		case " ", "space":
+			m.paused = m.ceartax.TogglePause()
+		case "q", "esc", "enter":
+			if m.ready {
+				return m, tea.Quit
+			}
+		default:
+			if m.ready {
+				m.rerun(msg.(tea.KeyMsg).String())
+			}
+		}
+	case tea.MouseMsg:
+		switch msg.(tea.MouseMsg).Button {
+		case tea.MouseButtonWheelUp:
+			m.logScroll = min(m.logScroll+1, max(len(m.logs)-logLines, 0))
+		case tea.MouseButtonWheelDown:
+			m.logScroll = max(m.logScroll-1, 0)
+		}
+	case logMsg:
+		m.logs = append(m.logs, string(msg.(logMsg)))
+		if len(m.logs) > logKeep {
+			m.logs = m.logs[len(m.logs)-logKeep:]
+		}
+		// Scrolled back: keep the same lines in view as new ones arrive.
+		if m.logScroll > 0 {
+			m.logScroll = min(m.logScroll+1, max(len(m.logs)-logLines, 0))
+		}
+		return m, m.logCmd()
+	case tea.WindowSizeMsg:
+		m.width = msg.(tea.WindowSizeMsg).Width
+	case progressMsg:
+		// progress.Model is a value: write it back, and return the
+		// animation command or the bar never moves.
+		p := msg.(progressMsg)
+		prog, ok := m.progress[p.module]
+		if !ok {
+			prog = progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
+		}
+		if p.value < 0 {
+			m.progress[p.module] = prog
+			m.counts[p.module] = p.done
+			return m, m.progressCmd()
+		}
+		delete(m.counts, p.module)
+		rate := m.rates[p.module]
+		if rate == nil {
+			rate = &progressRate{}
+			m.rates[p.module] = rate
+		}
+		rate.update(p.value, time.Now())
+		cmd := prog.SetPercent(p.value)
+		m.progress[p.module] = prog
+		return m, tea.Batch(cmd, m.progressCmd())
+	case progress.FrameMsg:
+		var cmds []tea.Cmd
+		for k, prog := range m.progress {
+			next, cmd := prog.Update(msg)
+			m.progress[k] = next.(progress.Model)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	case benchMsg:
+		m.benchmarks = append(m.benchmarks, msg.(benchMsg).b)
+		if m.finished() && (!m.stay || m.ceartax.interrupted.Load()) {
+			return m, tea.Quit
+		}
+		return m, m.benchCmd()
+	case doneMsg:
+		if m.finished() && (!m.stay || m.ceartax.interrupted.Load()) {
+			return m, tea.Quit
+		}
+		return m, m.doneCmd()
+	case interruptTimeoutMsg:
+		m.finishNow()
+		return m, tea.Quit
+	case signalMsg:
+		// Interrupt has run already. Nothing to wait for once saved;
+		// otherwise give the modules the same grace as Ctrl+C.
+		if m.ready {
+			return m, tea.Quit
+		}
+		m.phase = "Interrupted, saving partial results"
+		return m, tea.Tick(interruptGrace, func(time.Time) tea.Msg { return interruptTimeoutMsg{} })
+	case frameMsg:
+		select {
+		case <-m.repaintCh:
+		default:
+		}
+		if time.Since(m.memAt) >= memSampleEvery {
+			m.sampleMem()
+		}
+		return m, m.frameCmd()
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m model) View() string {
+	if !m.ready {
+		s := titleStyle.Width(m.width).Render(" CEARTAX v2.3 ") + "\n"
+		phase := m.phase
+		if m.paused {
+			phase += " " + warnStyle.Render("PAUSED (space to resume)")
+		}
+		s += fmt.Sprintf("%s %s | FPS: %.1f\n\n", m.spinner.View(), phase, m.fps)
+
+		order := []string{"sub", "crt", "dns", "ports", "fp", "fav", "waf", "tls", "dirs", "api", "shot"}
+		for _, k := range order {
+			if p, ok := m.progress[k]; ok {
+				label := map[string]string{"sub": "Subdomains", "crt": "CrtSh", "dns": "DNS", "ports": "Ports", "fp": "Fingerprint", "fav": "Favicon", "waf": "WAF", "tls": "TLS", "dirs": "Dirs", "api": "API Specs", "shot": "Screenshots"}[k]
+				view := p.View()
+				if r := m.rates[k]; r != nil {
+					view += fmt.Sprintf(" %3.0f%%", r.value*100)
+					if r.value < 1 {
+						view += " " + r.eta()
+					}
+				}
+				if n, ok := m.counts[k]; ok {
+					view = fmt.Sprintf("%s %d done", m.spinner.View(), n)
+				}
+				s += barStyle.Render(fmt.Sprintf(" %s: %s\n", label, view))
+			}
+		}
+		if len(m.logs) > 0 {
+			end := len(m.logs) - m.logScroll
+			s += "\n" + logStyle.Render(strings.Join(m.logs[max(end-logLines, 0):end], "\n")) + "\n"
+			if m.logScroll > 0 {
+				s += warnStyle.Render(fmt.Sprintf(" -%d lines (wheel down for newer)", m.logScroll)) + "\n"
+			}
+		}
+		return s
+	}
+
 	dur := time.Since(m.startTime)
 	s := successStyle.Render("RECON + BENCHMARK SELESAI\n\n")
 	s += fmt.Sprintf("Duration: %s | FPS Avg: %.1f\n", dur.Round(time.Millisecond), m.fps)
-	s += fmt.Sprintf("Memory: %d KB peak\n", runtime.MemStats{}.Alloc/1024)
+	s += fmt.Sprintf("Memory: %d KB peak\n", m.peakKB)
+	s += fmt.Sprintf("Traffic: %d requests | %s\n", m.ceartax.totalRequests.Load(), humanBytes(m.ceartax.totalBytes.Load()))
 	s += fmt.Sprintf("Output: %s\n", m.ceartax.output)
+	for _, e := range m.saveErrs {
+		s += warnStyle.Render("Output error: "+e) + "\n"
+	}
+	if m.stay {
+		var keys []string
+		for _, k := range rerunKeys {
+			for _, mod := range modules {
+				if mod.name == k.module && m.ceartax.selected(mod.name, mod.discovery, mod.optIn) {
+					keys = append(keys, fmt.Sprintf("[%s] %s", k.key, k.module))
+				}
+			}
+		}
+		s += "\nRe-run: " + strings.Join(keys, "  ") + "\n[q] quit\n"
+		if m.ceartax.streaming() {
+			s += warnStyle.Render("Streams closed with the first save: re-run findings go to the report files only") + "\n"
+		}
+	}
+	return s
+}
+
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// saveResults writes every sink from a snapshot of the result: after
+// Ctrl+C it runs while cancelled modules may still be writing to it.
+// Failures are kept for the results screen, since the TUI owns the
+// terminal while they happen; a re-run's save replaces the last list.
+func (m *model) saveResults() {
+	m.saveErrs = nil
+	m.ceartax.stampTotals()
+	r, err := m.ceartax.snapshot()
+	if err != nil {
+		m.saveErrs = append(m.saveErrs, err.Error())
+		return
+	}
+	for _, s := range m.ceartax.sinks {
+		if err := s.Write(r, m.benchmarks); err != nil {
+			m.saveErrs = append(m.saveErrs, err.Error())
+		}
+	}
+}
+
+// === OUTPUT SINKS ===
+// Sink writes the finished scan to one destination. Sinks that also
+// implement findingSink receive every finding as soon as it is found.
+type Sink interface {
+	Write(r *ReconResult, bench []Benchmark) error
+}
+
+type findingSink interface {
+	Emit(f Finding)
+}
+
+// emit hands a finding to the streaming sinks and trips the
+// -abort-on-findings breaker for the module that found it.
+func (c *Ceartax) emit(ctx context.Context, typ, value string) {
+	st := statsOf(ctx)
+	if st != nil && c.abortAfter > 0 {
+		if n := st.findings.Add(1); n == int64(c.abortAfter) {
+			st.abort(fmt.Sprintf("%d %s findings; target probably answers everything", n, typ))
+		}
+	}
+	f := Finding{ScanID: c.scanID, Type: typ, Target: c.target, Value: value, Time: time.Now()}
+	if st != nil {
+		f.Source = st.name
+	}
+	f.Interest = scoreFinding(f)
+	c.mu.Lock()
+	c.result.Findings = append(c.result.Findings, f)
+	c.mu.Unlock()
+	c.logf("%s %s", typ, value)
+	for _, s := range c.sinks {
+		if fs, ok := s.(findingSink); ok {
+			fs.Emit(f)
+		}
+	}
+}
+
+// buildSinks runs once in NewCeartax. Streaming sinks come first so their
+// delivery stats are in the result before the file sinks serialize it.
+func (c *Ceartax) buildSinks(cfg Config) []Sink {
+	var s []Sink
+	if cfg.BenchOnly || cfg.DryRun {
+		return nil
+	}
+	if cfg.ListOnly {
+		if c.splitDir != "" {
+			return []Sink{splitSink{dir: c.outPath(c.splitDir)}}
+		}
+		return nil
+	}
+	opts := streamOpts{batch: cfg.BatchSize, flush: cfg.FlushInterval}
+	if cfg.StreamFile != "" {
+		fs, err := newNDJSONFile(c.outPath(cfg.StreamFile), opts)
+		if err != nil {
+			log.Printf("-stream-file: %v", err)
+		} else {
+			s = append(s, fs)
+		}
+	}
+	if cfg.StreamURL != "" {
+		s = append(s, newHTTPStream(cfg.StreamURL, opts, c.client.Transport))
+	}
+	if cfg.ESURL != "" {
+		s = append(s, newESStream(cfg.ESURL, esIndexName(cfg.ESIndex), opts, c.client.Transport))
+	}
+	if cfg.ESFile != "" {
+		fs, err := newFileStream("es-file", c.outPath(cfg.ESFile), opts, esBulk(esIndexName(cfg.ESIndex)))
+		if err != nil {
+			log.Printf("-es-file: %v", err)
+		} else {
+			s = append(s, fs)
+		}
+	}
+	if c.output == stdoutPath {
+		s = append(s, jsonSink{path: stdoutPath})
+	} else if c.output != "" {
+		jsonPath, htmlPath := c.reportPaths()
+		if c.formats["json"] {
+			s = append(s, jsonSink{path: jsonPath})
+		}
+		if c.formats["html"] {
+			s = append(s, htmlSink{path: htmlPath})
+		}
+		if c.formats["txt"] {
+			s = append(s, textSink{path: c.reportPath(".txt")})
+		}
+		if c.formats["csv"] {
+			s = append(s, csvSink{path: c.reportPath(".csv")})
+		}
+		if c.formats["nmap-xml"] {
+			s = append(s, nmapSink{path: c.reportPath(".xml")})
+		}
+	}
+	if c.splitDir != "" {
+		s = append(s, splitSink{dir: c.outPath(c.splitDir)})
+	}
+	if cfg.AssetsOut != "" {
+		s = append(s, assetsSink{path: c.outPath(cfg.AssetsOut)})
+	}
+	if cfg.SARIFOut != "" {
+		s = append(s, sarifSink{path: c.outPath(cfg.SARIFOut)})
+	}
+	if c.certDir != "" {
+		s = append(s, certSink{dir: c.outPath(c.certDir), c: c})
+	}
+	if cfg.BenchOut != "" {
+		s = append(s, benchSink{path: c.outPath(cfg.BenchOut), c: c})
+	}
+	// Last, so the summary goes out after every file is written.
+	if cfg.WebhookURL != "" {
+		s = append(s, webhookSink{url: cfg.WebhookURL, format: cfg.WebhookFormat, c: c})
+	}
+	return s
+}
+
+// reportPaths are the JSON and HTML report paths. HTML swaps -output's
+// extension, whatever it is, so report, report.json and report.out all
+// give report.html; only -output report.html needs a second suffix.
+func (c *Ceartax) reportPaths() (jsonPath, htmlPath string) {
+	jsonPath, htmlPath = c.outPath(c.output), c.reportPath(".html")
+	if htmlPath == jsonPath {
+		htmlPath = c.outPath(c.output + ".html")
+	}
+	return jsonPath, htmlPath
+}
+
+// reportPath is -output with its extension swapped for ext.
+func (c *Ceartax) reportPath(ext string) string {
+	return c.outPath(strings.TrimSuffix(c.output, filepath.Ext(c.output)) + ext)
+}
+
+// outPath places relative output paths under -output-dir and then
+// -per-target-dir/<target>/, when those are set, so sweeps keep each
+// host's artifacts apart.
+//
+// In a -targets-file sweep without -per-target-dir the target goes into the
+// name instead: recon.json becomes recon_example.com.json. -timestamp adds
+// the scan start last: recon_example.com_20240501-120000.json.
+func (c *Ceartax) outPath(p string) string {
+	if p == stdoutPath {
+		return p
+	}
+	if c.perTargetDir != "" && filepath.IsAbs(p) {
+		return p
+	}
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	if c.perTargetDir == "" && c.multiTarget {
+		base += "_" + safeName(c.target)
+	}
+	if c.stamp != "" {
+		base += "_" + c.stamp
+	}
+	p = base + ext
+	if filepath.IsAbs(p) {
+		return p
+	}
+	if c.perTargetDir != "" {
+		p = filepath.Join(c.perTargetDir, safeName(c.target), p)
+	}
+	if c.outputDir != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(c.outputDir, p)
+	}
+	return p
+}
+
+// safeName makes a target usable as a single path component.
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// createFile is os.Create that also creates missing parent directories.
+func createFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// stdoutPath as -output writes the JSON report to stdout and nothing
+// else; meant for -headless runs piped into jq.
+const stdoutPath = "-"
+
+func writeFile(path string, data []byte) error {
+	if path == stdoutPath {
+		_, err := os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	f, err := createFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type jsonSink struct{ path string }
+
+func (j jsonSink) Write(r *ReconResult, _ []Benchmark) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(j.path, data)
+}
+
+// htmlSink renders the report with the benchmark graph.
+type htmlSink struct{ path string }
+
+func (h htmlSink) Write(r *ReconResult, bench []Benchmark) error {
+	// rel makes screenshot paths relative to the report so the images
+	// still show when the output dir is moved as a whole.
+	rel := func(p string) string {
+		if r, err := filepath.Rel(filepath.Dir(h.path), p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	tmpl := template.Must(template.New("report").Funcs(template.FuncMap{"join": strings.Join, "rel": rel}).Parse(htmlReportTemplate))
+	f, err := createFile(h.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	type Data struct {
+		Result ReconResult
+		Bench  []Benchmark
+	}
+	// Most interesting first; the copy keeps r in discovery order for
+	// the sinks after this one.
+	data := Data{Result: *r, Bench: bench}
+	data.Result.Findings = slices.Clone(r.Findings)
+	sort.SliceStable(data.Result.Findings, func(i, j int) bool {
+		return interestRank[data.Result.Findings[i].Interest] > interestRank[data.Result.Findings[j].Interest]
+	})
+	return tmpl.Execute(f, data)
+}
+
+// splitSink writes one finding per line per category, for piping into
+// other tools.
+type splitSink struct{ dir string }
+
+func (s splitSink) Write(r *ReconResult, _ []Benchmark) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	ports := make([]string, len(r.OpenPorts))
+	for i, p := range r.OpenPorts {
+		ports[i] = fmt.Sprint(p)
+	}
+	for _, hp := range r.rangePorts() {
+		ports = append(ports, net.JoinHostPort(hp.host, strconv.Itoa(hp.port)))
+	}
+	files := map[string][]string{
+		"subdomains.txt": r.Subdomains,
+		"ports.txt":      ports,
+		"dirs.txt":       r.Directories,
+	}
+	for name, lines := range files {
+		if err := writeLines(filepath.Join(s.dir, name), lines); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// certSink writes the first chain seen for each host as <host>.pem.
+type certSink struct {
+	dir string
+	c   *Ceartax
+}
+
+// Write exports the target's chain and those of resolved subdomains. Other
+// handshakes, such as the random catch-all probe host, are not exported.
+func (cs certSink) Write(r *ReconResult, _ []Benchmark) error {
+	cs.c.mu.Lock()
+	defer cs.c.mu.Unlock()
+	for host, chain := range cs.c.certChains {
+		if host == "" {
+			host = cs.c.target
+		}
+		if host != cs.c.target && !slices.Contains(r.Subdomains, host) {
+			continue
+		}
+		var buf bytes.Buffer
+		for _, cert := range chain {
+			pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+		if err := writeFile(filepath.Join(cs.dir, safeName(host)+".pem"), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchSink writes the benchmarks on their own, sorted by module so runs
+// diff cleanly, with totals for the whole scan.
+type benchSink struct {
+	path string
+	c    *Ceartax
+}
+
+type benchTotals struct {
+	WallMs   int64   `json:"wall_ms"` // first module start to last module end
+	Requests int64   `json:"requests"`
+	Bytes    int64   `json:"bytes"`
+	RPS      float64 `json:"rps"` // requests over wall time
+	PeakKB   uint64  `json:"peak_mem_kb"`
+}
+
+func (b benchSink) Write(r *ReconResult, bench []Benchmark) error {
+	mods := slices.Clone(bench)
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Module < mods[j].Module })
+	var t benchTotals
+	var first, last time.Time
+	for _, m := range mods {
+		if first.IsZero() || m.Start.Before(first) {
+			first = m.Start
+		}
+		if m.End.After(last) {
+			last = m.End
+		}
+		t.PeakKB = max(t.PeakKB, m.MemoryPre, m.MemoryPost)
+	}
+	t.PeakKB = max(t.PeakKB, b.c.memKB())
+	wall := last.Sub(first)
+	t.WallMs = wall.Milliseconds()
+	t.Requests = b.c.totalRequests.Load()
+	t.Bytes = b.c.totalBytes.Load()
+	if wall > 0 {
+		t.RPS = float64(t.Requests) / wall.Seconds()
+	}
+	data, err := json.MarshalIndent(struct {
+		ScanID  string      `json:"scan_id"`
+		Target  string      `json:"target"`
+		Modules []Benchmark `json:"modules"`
+		Total   benchTotals `json:"total"`
+	}{r.ScanID, r.Target, mods, t}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(b.path, data)
+}
+
+// webhookSink POSTs a short summary when the scan is saved, over the
+// scan's transport, so -proxy, -proxy-file and -4/-6 apply as for the
+// scan. An unreachable hook is logged, never a failed save.
+type webhookSink struct {
+	url    string
+	format string // json or slack
+	c      *Ceartax
+}
+
+type webhookSummary struct {
+	ScanID      string `json:"scan_id"`
+	Target      string `json:"target"`
+	Duration    string `json:"duration"`
+	Subdomains  int    `json:"subdomains"`
+	OpenPorts   int    `json:"open_ports"`
+	Directories int    `json:"directories"`
+	Findings    int    `json:"findings"`
+	Output      string `json:"output,omitempty"`
+}
+
+const webhookTimeout = 10 * time.Second
+
+func (w webhookSink) Write(r *ReconResult, _ []Benchmark) error {
+	sum := webhookSummary{
+		ScanID:      r.ScanID,
+		Target:      r.Target,
+		Duration:    time.Since(r.Timestamp).Round(time.Second).String(),
+		Subdomains:  len(r.Subdomains),
+		OpenPorts:   len(r.OpenPorts) + len(r.rangePorts()),
+		Directories: len(r.Directories),
+		Findings:    len(r.Findings),
+	}
+	if w.c.output != "" && w.c.formats["json"] || w.c.output == stdoutPath {
+		sum.Output, _ = w.c.reportPaths()
+	}
+	var payload any = sum
+	if w.format == "slack" {
+		text := fmt.Sprintf("Ceartax scan of %s finished in %s: %d subdomains, %d open ports, %d directories, %d findings.",
+			sum.Target, sum.Duration, sum.Subdomains, sum.OpenPorts, sum.Directories, sum.Findings)
+		if sum.Output != "" {
+			text += " Report: " + sum.Output
+		}
+		payload = map[string]string{"text": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: w.c.client.Transport, Timeout: webhookTimeout}
+	resp, err := client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("-webhook-url: %v", err)
+		return nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("-webhook-url: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Asset is one row of the -assets-out inventory, the shape attack-surface
+// management tools import: one network endpoint and what we know about it.
+type Asset struct {
+	ScanID  string   `json:"scan_id"`
+	Host    string   `json:"host"`
+	IP      string   `json:"ip,omitempty"`
+	Port    int      `json:"port,omitempty"`
+	Service string   `json:"service,omitempty"`
+	Tech    []string `json:"tech,omitempty"`
+	Source  []string `json:"source"`
+}
+
+var portServices = map[int]string{
+	21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "dns", 80: "http",
+	110: "pop3", 143: "imap", 443: "https", 445: "smb", 993: "imaps",
+	995: "pop3s", 1433: "mssql", 3306: "mysql", 3389: "rdp", 5432: "postgresql",
+	6379: "redis", 8080: "http-alt", 8443: "https-alt", 27017: "mongodb",
+}
+
+type assetsSink struct{ path string }
+
+func (a assetsSink) Write(r *ReconResult, _ []Benchmark) error {
+	data, err := json.MarshalIndent(buildAssets(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(a.path, data)
+}
+
+// buildAssets flattens the per-module result sections into one list.
+func buildAssets(r *ReconResult) []Asset {
+	var tech []string
+	for k, v := range r.TechStack {
+		if k != "service" {
+			tech = append(tech, v)
+		}
+	}
+	sort.Strings(tech)
+
+	var out []Asset
+	for _, host := range r.Subdomains {
+		ips := r.SubdomainIPs[host]
+		if len(ips) == 0 {
+			ips = []string{""}
+		}
+		for _, ip := range ips {
+			out = append(out, Asset{Host: host, IP: ip, Source: []string{"subdomains"}})
+		}
+	}
+	webSeen := false
+	for _, p := range r.OpenPorts {
+		a := Asset{Host: r.Target, Port: p, Service: portServices[p], Source: []string{"ports"}}
+		if p == 80 || p == 443 {
+			webSeen = true
+			if len(tech) > 0 {
+				a.Tech = tech
+				a.Source = append(a.Source, "fingerprint")
+			}
+		}
+		if p == 443 && r.TechStack["service"] != "" {
+			a.Service = r.TechStack["service"]
+		}
+		out = append(out, a)
+	}
+	for _, hp := range r.rangePorts() {
+		out = append(out, Asset{Host: hp.host, IP: hp.host, Port: hp.port, Service: portServices[hp.port], Source: []string{"ports"}})
+	}
+	if !webSeen && len(tech) > 0 {
+		out = append(out, Asset{Host: r.Target, Port: 443, Service: "https", Tech: tech, Source: []string{"fingerprint"}})
+	}
+	for i := range out {
+		out[i].ScanID = r.ScanID
+	}
+	return out
+}
+
+// sarifRules maps each finding category to a SARIF rule. Levels are the
+// SARIF ones: error, warning, note.
+var sarifRules = []struct{ id, level, desc string }{
+	{"exposed-path", "warning", "Path reachable on the target"},
+	{"exposed-vcs", "error", "Version control metadata exposed"},
+	{"tls-untrusted", "error", "Certificate chain does not verify"},
+	{"missing-header", "note", "Security header not set"},
+	{"header-anomaly", "warning", "Protocol anomaly in the response head"},
+	{"open-port", "note", "Open TCP port"},
+	{"live-subdomain", "note", "Subdomain serving its own content"},
+}
+
+// securityHeaders are reported as missing-header when absent from /.
+var securityHeaders = []string{
+	"Strict-Transport-Security", "Content-Security-Policy",
+	"X-Content-Type-Options", "X-Frame-Options",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool `json:"tool"`
+	AutomationDetails struct {
+		GUID string `json:"guid"`
+	} `json:"automationDetails"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	DefaultConfig    struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+type sarifSink struct{ path string }
+
+func (s sarifSink) Write(r *ReconResult, _ []Benchmark) error {
+	f, err := createFile(s.path)
+	if err != nil {
+		return err
+	}
+	if err := writeSARIF(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeSARIF(w io.Writer, r *ReconResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(buildSARIF(r))
+}
+
+// buildSARIF turns the result into a SARIF 2.1.0 log with one result per
+// finding, located by the URI of the affected endpoint.
+func buildSARIF(r *ReconResult) sarifLog {
+	var run sarifRun
+	run.Tool.Driver.Name = "Ceartax"
+	run.AutomationDetails.GUID = r.ScanID
+	levels := make(map[string]string)
+	for _, rule := range sarifRules {
+		sr := sarifRule{ID: rule.id, ShortDescription: sarifMessage{rule.desc}}
+		sr.DefaultConfig.Level = rule.level
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sr)
+		levels[rule.id] = rule.level
+	}
+	add := func(rule, uri, msg string) {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = uri
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    rule,
+			Level:     levels[rule],
+			Message:   sarifMessage{msg},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	root := "https://" + r.Target + "/"
+	for _, u := range r.Directories {
+		rule := "exposed-path"
+		if strings.Contains(u, "/.git") || strings.Contains(u, "/.svn") || strings.Contains(u, "/.hg") {
+			rule = "exposed-vcs"
+		}
+		add(rule, u, u+" is reachable")
+	}
+	if r.TLSInfo["trusted"] == "false" {
+		add("tls-untrusted", root, "TLS chain rejected: "+r.TLSInfo["trust_error"])
+	}
+	if len(r.Headers) > 0 {
+		for _, h := range securityHeaders {
+			if len(r.Headers[strings.ToLower(h)]) == 0 {
+				add("missing-header", root, h+" is not set")
+			}
+		}
+	}
+	for _, a := range r.HeaderAnomalies {
+		add("header-anomaly", root, a)
+	}
+	for _, p := range r.OpenPorts {
+		add("open-port", fmt.Sprintf("tcp://%s:%d", r.Target, p), fmt.Sprintf("port %d/tcp is open", p))
+	}
+	for _, hp := range r.rangePorts() {
+		addr := net.JoinHostPort(hp.host, strconv.Itoa(hp.port))
+		add("open-port", "tcp://"+addr, fmt.Sprintf("port %d/tcp is open on %s", hp.port, hp.host))
+	}
+	for _, h := range r.LiveSubdomains {
+		add("live-subdomain", "https://"+h+"/", h+" serves its own content")
+	}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+const (
+	streamQueue   = 1000
+	streamBatch   = 100
+	streamFlush   = 2 * time.Second
+	streamRetries = 3
+)
+
+// streamOpts tunes when a streamSink sends: after batch findings or every
+// flush, whichever comes first. Zero values mean the defaults above.
+type streamOpts struct {
+	batch int
+	flush time.Duration
+}
+
+// streamSink batches findings and hands each batch to deliver. Emit never
+// blocks the scan: when the queue is full (destination slow or down) the
+// finding is dropped and counted instead. deliver reports how many of the
+// batch were accepted; an error means none were and the batch is retried.
+type streamSink struct {
+	name      string
+	encode    func(buf *bytes.Buffer, f Finding)
+	deliver   func(body []byte, n int) (int, error)
+	flush     func() error // optional, runs on every tick and after the final batch
+	close     func() error // optional, runs after the final flush
+	opts      streamOpts
+	queue     chan Finding
+	done      chan struct{}
+	mu        sync.RWMutex // Emit vs. closing the queue
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+func newStreamSink(name string, opts streamOpts, encode func(*bytes.Buffer, Finding), deliver func([]byte, int) (int, error)) *streamSink {
+	if opts.batch <= 0 {
+		opts.batch = streamBatch
+	}
+	if opts.flush <= 0 {
+		opts.flush = streamFlush
+	}
+	s := &streamSink{
+		name:    name,
+		encode:  encode,
+		deliver: deliver,
+		opts:    opts,
+		queue:   make(chan Finding, max(streamQueue, opts.batch)),
+		done:    make(chan struct{}),
+	}
+	go s.loop()
 	return s
 }
 
-func (m *model) saveResults() {
-	// JSON
-	data, _ := json.MarshalIndent(m.ceartax.result, "", "  ")
-	os.WriteFile(m.ceartax.output, data, 0644)
+func ndjson(buf *bytes.Buffer, f Finding) { json.NewEncoder(buf).Encode(f) }
+
+// newHTTPStream POSTs plain NDJSON batches to a collector over rt, the
+// scan's transport, so -proxy and -proxy-file apply to it too.
+func newHTTPStream(url string, opts streamOpts, rt http.RoundTripper) *streamSink {
+	client := &http.Client{Transport: rt, Timeout: 10 * time.Second}
+	return newStreamSink("http", opts, ndjson, func(body []byte, n int) (int, error) {
+		resp, err := client.Post(url, "application/x-ndjson", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return 0, fmt.Errorf("collector: HTTP %d", resp.StatusCode)
+		}
+		return n, nil
+	})
+}
+
+// esIndexName expands {date} the way Logstash-style daily indices do.
+func esIndexName(tmpl string) string {
+	return strings.ReplaceAll(tmpl, "{date}", time.Now().UTC().Format("2006.01.02"))
+}
+
+// esBulk encodes a finding as an Elasticsearch _bulk action + document.
+func esBulk(index string) func(*bytes.Buffer, Finding) {
+	return func(buf *bytes.Buffer, f Finding) {
+		enc := json.NewEncoder(buf)
+		enc.Encode(map[string]any{"index": map[string]string{"_index": index}})
+		enc.Encode(struct {
+			Finding
+			Timestamp time.Time `json:"@timestamp"`
+		}{f, f.Time})
+	}
+}
+
+// newESStream posts to <es-url>/_bulk over rt, the scan's transport. ES
+// answers 200 even when some documents were rejected, so the per-item
+// statuses decide what counts as delivered. A reply that does not decode
+// proves nothing, so that batch counts as dropped; retrying could index
+// it twice.
+func newESStream(esURL, index string, opts streamOpts, rt http.RoundTripper) *streamSink {
+	client := &http.Client{Transport: rt, Timeout: 30 * time.Second}
+	bulkURL := strings.TrimSuffix(esURL, "/") + "/_bulk"
+	return newStreamSink("elasticsearch", opts, esBulk(index), func(body []byte, n int) (int, error) {
+		resp, err := client.Post(bulkURL, "application/x-ndjson", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return 0, fmt.Errorf("elasticsearch: HTTP %d", resp.StatusCode)
+		}
+		var br struct {
+			Errors bool `json:"errors"`
+			Items  []map[string]struct {
+				Status int `json:"status"`
+			} `json:"items"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&br); err != nil {
+			return 0, nil
+		}
+		if !br.Errors {
+			return n, nil
+		}
+		ok := 0
+		for _, item := range br.Items {
+			for _, res := range item {
+				if res.Status < 300 {
+					ok++
+				}
+			}
+		}
+		return ok, nil
+	})
+}
+
+// newFileStream appends encoded batches to a local file, e.g. a _bulk
+// file for later `curl --data-binary @file`. Writes go through a buffer
+// that is flushed on every tick, so tailing consumers stay current
+// without a syscall per batch.
+func newFileStream(name, path string, opts streamOpts, encode func(*bytes.Buffer, Finding)) (*streamSink, error) {
+	f, err := createFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriterSize(f, 64<<10)
+	s := newStreamSink(name, opts, encode, func(body []byte, n int) (int, error) {
+		if _, err := bw.Write(body); err != nil {
+			return 0, err
+		}
+		return n, nil
+	})
+	s.flush = bw.Flush
+	s.close = f.Close
+	return s, nil
+}
+
+// newNDJSONFile appends each finding to path as one JSON line the
+// moment it is found: batches of one, written unbuffered, so `tail -f`
+// and jq see every line complete. The sink's single writer goroutine
+// serializes the concurrent emitters.
+func newNDJSONFile(path string, opts streamOpts) (*streamSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	opts.batch = 1
+	s := newStreamSink("file", opts, ndjson, func(body []byte, n int) (int, error) {
+		if _, err := f.Write(body); err != nil {
+			return 0, err
+		}
+		return n, nil
+	})
+	s.close = f.Close
+	return s, nil
+}
+
+func (s *streamSink) Emit(f Finding) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.queue <- f:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *streamSink) loop() {
+	defer close(s.done)
+	batch := make([]Finding, 0, s.opts.batch)
+	tick := time.NewTicker(s.opts.flush)
+	defer tick.Stop()
+	for {
+		select {
+		case f, ok := <-s.queue:
+			if !ok {
+				s.send(batch)
+				if s.flush != nil {
+					s.flush()
+				}
+				return
+			}
+			if batch = append(batch, f); len(batch) >= s.opts.batch {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-tick.C:
+			s.send(batch)
+			batch = batch[:0]
+			if s.flush != nil {
+				s.flush()
+			}
+		}
+	}
+}
+
+func (s *streamSink) send(batch []Finding) {
+	if len(batch) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	for _, f := range batch {
+		s.encode(&buf, f)
+	}
+	for attempt := 0; attempt < streamRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		n, err := s.deliver(buf.Bytes(), len(batch))
+		if err != nil {
+			continue
+		}
+		s.delivered.Add(int64(n))
+		s.dropped.Add(int64(len(batch) - n))
+		return
+	}
+	s.dropped.Add(int64(len(batch)))
+}
+
+// streaming reports whether any sink streams findings as they are found.
+func (c *Ceartax) streaming() bool {
+	for _, s := range c.sinks {
+		if _, ok := s.(*streamSink); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// flushStreams drains the streaming sinks. After a normal run saveResults
+// already has; after Ctrl+C this keeps queued findings from being lost.
+func (c *Ceartax) flushStreams() {
+	var scratch ReconResult
+	for _, s := range c.sinks {
+		if ss, ok := s.(*streamSink); ok {
+			if err := ss.Write(&scratch, nil); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// Write drains whatever is still queued and records the delivery stats.
+// It runs on every save (a re-run saves again, then flushStreams in main);
+// only the first call closes the queue, and findings emitted after it
+// count as dropped. Later calls still fill r.Streams and report drops.
+func (s *streamSink) Write(r *ReconResult, _ []Benchmark) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	s.closeOnce.Do(func() {
+		if s.close != nil {
+			s.closeErr = s.close()
+		}
+	})
+	if s.closeErr != nil {
+		return s.closeErr
+	}
+	st := StreamStats{Delivered: s.delivered.Load(), Dropped: s.dropped.Load()}
+	if r.Streams == nil {
+		r.Streams = make(map[string]StreamStats)
+	}
+	r.Streams[s.name] = st
+	if st.Dropped > 0 {
+		return fmt.Errorf("%s: %d findings dropped", s.name, st.Dropped)
+	}
+	return nil
+}
 
-	// HTML with benchmark graph
-	tmpl := template.Must(template.New("report").Parse(htmlReportTemplate))
-	f, _ := os.Create(strings.Replace(m.ceartax.output, ".json", ".html", 1))
-	type Data struct {
-		Result ReconResult
-		Bench  []Benchmark
+func writeLines(path string, lines []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
 	}
-	tmpl.Execute(f, Data{Result: m.ceartax.result, Bench: m.benchmarks})
-	f.Close()
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 const htmlReportTemplate = `<!DOCTYPE html><html><head><title>Ceartax Report</title>
 <style>body{font:14px monospace;background:#000;color:#0f0;padding:20px;}
 table,th,td{border:1px solid #0f0;border-collapse:collapse;padding:8px;}
-canvas{border:1px solid #0f0;}</style>
+canvas{border:1px solid #0f0;}
+tr.high{background:#500;color:#fff;} tr.medium{background:#530;color:#fff;} tr.low{color:#ff0;} tr.info{color:#0f0;}</style>
 <script src="https://cdn.jsdelivr.net/npm/chart.js"></script>
 </head><body>
 <h1>Ceartax v2.3 Report</h1>
-<p><b>Target:</b> {{.Result.Target}} | <b>Time:</b> {{.Result.Timestamp}}</p>
+<p><b>Target:</b> {{.Result.Target}} | <b>Time:</b> {{.Result.Timestamp}} | <b>Scan:</b> {{.Result.ScanID}}</p>
+<p><b>Requests:</b> {{.Result.TotalRequests}} | <b>Bytes:</b> {{.Result.TotalBytes}}</p>
 
 <h2>Performance Benchmark</h2>
 <canvas id="benchChart" width="800" height="400"></canvas>
@@ -443,35 +5560,1149 @@ new Chart(ctx, {
   options: { scales: { y1: { position: 'right' } } }
 });
 </script>
+<table><tr><th>Module</th><th>Duration</th><th>Active</th><th>Wait</th><th>Requests</th><th>Errors</th><th>Retries</th><th>RPS</th><th>Status</th></tr>
+{{range .Bench}}<tr><td>{{.Module}}</td><td>{{.Duration}}</td><td>{{.ActiveDuration}}</td><td>{{.WaitDuration}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{.Retries}}</td><td>{{printf "%.2f" .RPS}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
 
+{{range $m, $why := .Result.Aborted}}<p><b>{{$m}} aborted:</b> {{$why}}</p>{{end}}
+{{range $m, $err := .Result.Failed}}<p><b>{{$m}} failed:</b> {{$err}}</p>{{end}}
+{{range $h, $why := .Result.Unreachable}}<p><b>{{$h}} unreachable:</b> {{$why}}</p>{{end}}
 <h2>Findings</h2>
+{{if .Result.Findings}}<table><tr><th>Interest</th><th>Type</th><th>Value</th><th>Source</th></tr>
+{{range .Result.Findings}}<tr class="{{.Interest}}"><td>{{.Interest}}</td><td>{{.Type}}</td><td>{{.Value}}</td><td>{{.Source}}</td></tr>
+{{end}}</table>{{end}}
+{{with .Result.RedirectChain}}<p><b>Redirects:</b> {{join . " → "}}</p>{{end}}
+{{with .Result.HTTPVersions}}<p><b>HTTP versions:</b>{{range $v, $ok := .}} {{$v}} {{if $ok}}yes{{else}}no{{end}}{{end}}</p>{{end}}
+{{if .Result.Headers}}<h3>Response Headers</h3>
+<table>{{range $k, $vals := .Result.Headers}}{{range $vals}}<tr><td>{{$k}}</td><td>{{.}}</td></tr>{{end}}{{end}}</table>{{end}}
+{{with .Result.DNSRecords}}<h3>DNS Records</h3>
+<table><tr><th>Type</th><th>Value</th></tr>
+{{range $t, $vals := .}}{{range $vals}}<tr><td>{{$t}}</td><td>{{.}}</td></tr>{{end}}{{end}}</table>{{end}}
 <ul>{{range .Result.Subdomains}}<li>{{.}}</li>{{end}}</ul>
+{{if .Result.TLSInfo}}<h3>TLS</h3>
+<table>{{range $k, $v := .Result.TLSInfo}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>{{end}}
+{{if .Result.OpenPorts}}<h3>Open Ports</h3>
+<table><tr><th>Port</th><th>Connect (ms)</th><th>Banner</th></tr>
+{{range .Result.OpenPorts}}<tr><td>{{.}}</td><td>{{index $.Result.ConnectMs .}}</td><td>{{index $.Result.Banners .}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Result.HostPorts}}<h3>Open Ports by Host</h3>
+<table><tr><th>Address</th><th>Ports</th></tr>
+{{range $h, $ps := .Result.HostPorts}}<tr><td>{{$h}}</td><td>{{range $ps}}{{.}} {{end}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Result.ClosedMs}}<h3>Closed Ports</h3>
+<table><tr><th>Port</th><th>RST (ms)</th></tr>
+{{range $p, $ms := .Result.ClosedMs}}<tr><td>{{$p}}</td><td>{{$ms}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Result.MethodProbes}}<h3>HTTP Methods</h3>
+<table><tr><th>Method</th><th>Status</th><th>Length</th><th>Latency (ms)</th><th>Differs from GET</th></tr>
+{{range .Result.MethodProbes}}<tr><td>{{.Method}}</td><td>{{.Status}}</td><td>{{.Length}}</td><td>{{.LatencyMs}}</td><td>{{.Differs}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Result.HeaderAnomalies}}<h3>Header Anomalies</h3>
+<ul>{{range .Result.HeaderAnomalies}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Result.APISpecs}}<h3>API Specs</h3>
+<ul>{{range .Result.APISpecs}}<li>{{.URL}} ({{.Kind}}{{with .Version}} {{.}}{{end}})</li>{{end}}</ul>
+{{if .Result.Endpoints}}<table><tr><th>Method</th><th>Path</th></tr>
+{{range .Result.Endpoints}}<tr><td>{{.Method}}</td><td>{{.Path}}</td></tr>
+{{end}}</table>{{end}}{{end}}
+{{if .Result.DirStatuses}}<h3>Probed Paths</h3>
+<table><tr><th>URL</th><th>Status</th></tr>
+{{range $u, $s := .Result.DirStatuses}}<tr><td>{{$u}}</td><td>{{$s}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Result.Screenshots}}<h3>Screenshots</h3>
+<div>{{range .Result.Screenshots}}<figure style="display:inline-block;margin:4px"><a href="{{rel .File}}"><img src="{{rel .File}}" width="320" alt="{{.URL}}"></a><figcaption>{{.URL}}</figcaption></figure>
+{{end}}</div>{{end}}
+{{if .Result.Matches}}<h3>Grep Matches</h3>
+<table><tr><th>URL</th><th>Match</th></tr>
+{{range .Result.Matches}}<tr><td>{{.URL}}</td><td><code>{{.Text}}</code></td></tr>
+{{end}}</table>{{end}}
+{{if .Result.PageIssues}}<h3>Pages Cut Short</h3>
+<ul>{{range .Result.PageIssues}}<li>{{.URL}} ({{.Issue}})</li>{{end}}</ul>{{end}}
+{{with .Result.LoadBalancer}}<h3>Load Balancer</h3>
+<p>Likely: {{.Likely}} | Confidence: {{.Confidence}} ({{.Samples}} samples)</p>
+<ul>{{range .Evidence}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<h3>Live Subdomains</h3>
+<ul>{{range .Result.LiveSubdomains}}<li>{{.}}</li>{{end}}</ul>
+{{with .Result.SuppressionLog}}<h3>Suppressed</h3>
+{{with .CatchAll}}<p>Catch-all via {{.Method}} for {{.Probe}}{{if .IPs}} ({{join .IPs ", "}}){{end}}: {{.Hash}}</p>{{end}}
+<table><tr><th>Host</th><th>Reason</th><th>Evidence</th></tr>
+{{range .Hosts}}<tr><td>{{.Host}}</td><td>{{.Reason}}</td><td>{{.Evidence}}</td></tr>
+{{end}}</table>{{end}}
 </body></html>`
 
+// textSink is the plain-text report: no markup, for cat, mail and archives.
+type textSink struct{ path string }
+
+func (t textSink) Write(r *ReconResult, bench []Benchmark) error {
+	return writeText(t.path, r, bench)
+}
+
+func writeText(path string, r *ReconResult, bench []Benchmark) error {
+	f, err := createFile(path)
+	if err != nil {
+		return err
+	}
+	type Data struct {
+		Result ReconResult
+		Bench  []Benchmark
+	}
+	if err := textReport.Execute(f, Data{Result: *r, Bench: bench}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var textReport = texttemplate.Must(texttemplate.New("text").Funcs(texttemplate.FuncMap{"join": strings.Join}).Parse(textReportTemplate))
+
+const textReportTemplate = `CEARTAX v2.3 REPORT
+Target:   {{.Result.Target}}
+Scan:     {{.Result.ScanID}}
+Time:     {{.Result.Timestamp.Format "2006-01-02 15:04:05 MST"}}
+Traffic:  {{.Result.TotalRequests}} requests, {{.Result.TotalBytes}} bytes
+
+BENCHMARK
+{{range .Bench}}  {{printf "%-12s %10v %6d req %4d err  %s" .Module .Duration .Requests .Errors .Status}}
+{{end}}{{range $m, $why := .Result.Aborted}}  {{$m}} aborted: {{$why}}
+{{end}}{{range $m, $err := .Result.Failed}}  {{$m}} failed: {{$err}}
+{{end}}{{range $h, $why := .Result.Unreachable}}  {{$h}} unreachable: {{$why}}
+{{end}}
+SUBDOMAINS ({{len .Result.Subdomains}})
+{{range .Result.Subdomains}}  {{.}}{{with index $.Result.SubdomainIPs .}}  [{{join . ", "}}]{{end}}
+{{else}}  (none)
+{{end}}
+LIVE SUBDOMAINS ({{len .Result.LiveSubdomains}})
+{{range .Result.LiveSubdomains}}  {{.}}
+{{else}}  (none)
+{{end}}
+OPEN PORTS ({{len .Result.OpenPorts}})
+{{range .Result.OpenPorts}}  {{printf "%-6d" .}} connect {{index $.Result.ConnectMs .}} ms{{with index $.Result.Banners .}}  {{.}}{{end}}
+{{else}}  (none)
+{{end}}
+{{- range $h, $ps := .Result.HostPorts}}  {{$h}}: {{range $ps}}{{.}} {{end}}
+{{end}}
+DIRECTORIES ({{len .Result.Directories}})
+{{range .Result.Directories}}  {{.}}
+{{else}}  (none)
+{{end}}
+TLS
+{{range $k, $v := .Result.TLSInfo}}  {{$k}}: {{$v}}
+{{else}}  (none)
+{{end}}
+TECH STACK
+{{range $k, $v := .Result.TechStack}}  {{$k}}: {{$v}}
+{{else}}  (none)
+{{end}}{{with .Result.HTTPVersions}}  supports:{{range $v, $ok := .}} {{$v}} {{if $ok}}yes{{else}}no{{end}}{{end}}
+{{end}}
+FINDINGS ({{len .Result.Findings}})
+{{range .Result.Findings}}  {{printf "%-6s %-14s %-12s" .Interest .Type .Source}} {{.Value}}
+{{else}}  (none)
+{{end}}{{if .Result.APISpecs}}
+API SPECS
+{{range .Result.APISpecs}}  {{.URL}} ({{.Kind}}{{with .Version}} {{.}}{{end}})
+{{end}}{{range .Result.Endpoints}}    {{printf "%-7s" .Method}} {{.Path}}
+{{end}}{{end}}`
+
+// csvSink writes one table for spreadsheets: section, name, value. The
+// section column (subdomain, port, directory, header) splits it into
+// one block per finding type; encoding/csv quotes commas, quotes and
+// newlines in header values.
+type csvSink struct{ path string }
+
+func (s csvSink) Write(r *ReconResult, _ []Benchmark) error {
+	f, err := createFile(s.path)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{{"section", "name", "value"}}
+	for _, h := range r.Subdomains {
+		rows = append(rows, []string{"subdomain", h, strings.Join(r.SubdomainIPs[h], " ")})
+	}
+	for _, p := range r.OpenPorts {
+		rows = append(rows, []string{"port", strconv.Itoa(p), r.Banners[p]})
+	}
+	for _, hp := range r.rangePorts() {
+		rows = append(rows, []string{"port", net.JoinHostPort(hp.host, strconv.Itoa(hp.port)), ""})
+	}
+	for _, d := range r.Directories {
+		rows = append(rows, []string{"directory", d, strconv.Itoa(r.DirStatuses[d])})
+	}
+	names := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		for _, v := range r.Headers[k] {
+			rows = append(rows, []string{"header", k, v})
+		}
+	}
+	if err := csv.NewWriter(f).WriteAll(rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// nmapSink writes Ports' findings as nmap -oX would, for tools that only
+// ingest that: one <host> per scanned address, each open port with a
+// <service> named from portServices and, when a banner came back, the
+// banner as its product. Not byte-identical to nmap, but the elements and
+// attributes parsers read are all there.
+type nmapSink struct{ path string }
+
+type nmapRun struct {
+	XMLName   xml.Name   `xml:"nmaprun"`
+	Scanner   string     `xml:"scanner,attr"`
+	Args      string     `xml:"args,attr"`
+	Start     int64      `xml:"start,attr"`
+	StartStr  string     `xml:"startstr,attr"`
+	Version   string     `xml:"version,attr"`
+	XMLOut    string     `xml:"xmloutputversion,attr"`
+	ScanInfo  nmapInfo   `xml:"scaninfo"`
+	Hosts     []nmapHost `xml:"host"`
+	RunStats  nmapStats  `xml:"runstats"`
+	Generator string     `xml:",comment"`
+}
+
+type nmapInfo struct {
+	Type     string `xml:"type,attr"`
+	Protocol string `xml:"protocol,attr"`
+}
+
+type nmapHost struct {
+	Status    nmapState      `xml:"status"`
+	Address   []nmapAddress  `xml:"address"`
+	Hostnames []nmapHostname `xml:"hostnames>hostname"`
+	Ports     []nmapPort     `xml:"ports>port"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPort struct {
+	Protocol string      `xml:"protocol,attr"`
+	PortID   int         `xml:"portid,attr"`
+	State    nmapState   `xml:"state"`
+	Service  nmapService `xml:"service"`
+}
+
+type nmapState struct {
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
+}
+
+type nmapService struct {
+	Name    string `xml:"name,attr"`
+	Product string `xml:"product,attr,omitempty"`
+	Method  string `xml:"method,attr"`
+	Conf    int    `xml:"conf,attr"`
+}
+
+type nmapStats struct {
+	Finished struct {
+		Time    int64  `xml:"time,attr"`
+		TimeStr string `xml:"timestr,attr"`
+	} `xml:"finished"`
+	Hosts struct {
+		Up    int `xml:"up,attr"`
+		Down  int `xml:"down,attr"`
+		Total int `xml:"total,attr"`
+	} `xml:"hosts"`
+}
+
+func (s nmapSink) Write(r *ReconResult, _ []Benchmark) error {
+	data, err := xml.MarshalIndent(buildNmap(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, append([]byte(xml.Header+"<!DOCTYPE nmaprun>\n"), append(data, '\n')...))
+}
+
+// buildNmap maps OpenPorts and Banners (or HostPorts for a CIDR target)
+// into nmaprun. A hostname target only gets an <address> when the scan
+// resolved it; the hostname itself is always listed.
+func buildNmap(r *ReconResult) nmapRun {
+	run := nmapRun{
+		Scanner:   "ceartax",
+		Args:      "ceartax -url " + r.Target,
+		Start:     r.Timestamp.Unix(),
+		StartStr:  r.Timestamp.Format(time.ANSIC),
+		Version:   "2.3",
+		XMLOut:    "1.05",
+		ScanInfo:  nmapInfo{Type: "connect", Protocol: "tcp"},
+		Generator: " ceartax scan " + r.ScanID + " ",
+	}
+	port := func(p int, banner string) nmapPort {
+		np := nmapPort{Protocol: "tcp", PortID: p, State: nmapState{State: "open", Reason: "syn-ack"}}
+		np.Service = nmapService{Name: portServices[p], Method: "table", Conf: 3}
+		if np.Service.Name == "" {
+			np.Service.Name = "unknown"
+		}
+		if banner != "" {
+			np.Service.Product, np.Service.Method, np.Service.Conf = banner, "probed", 10
+		}
+		return np
+	}
+	address := func(ip string) []nmapAddress {
+		a, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil
+		}
+		t := "ipv4"
+		if a.Unmap().Is6() {
+			t = "ipv6"
+		}
+		return []nmapAddress{{Addr: ip, AddrType: t}}
+	}
+	up := nmapState{State: "up", Reason: "user-set"}
+	if r.HostPorts != nil {
+		var last *nmapHost
+		for _, hp := range r.rangePorts() {
+			if last == nil || last.Address[0].Addr != hp.host {
+				run.Hosts = append(run.Hosts, nmapHost{Status: up, Address: address(hp.host)})
+				last = &run.Hosts[len(run.Hosts)-1]
+			}
+			last.Ports = append(last.Ports, port(hp.port, ""))
+		}
+	} else {
+		h := nmapHost{Status: up, Address: address(r.Target)}
+		if h.Address == nil {
+			if ips := r.SubdomainIPs[r.Target]; len(ips) > 0 {
+				h.Address = address(ips[0])
+			}
+			h.Hostnames = []nmapHostname{{Name: r.Target, Type: "user"}}
+		}
+		for _, p := range r.OpenPorts {
+			h.Ports = append(h.Ports, port(p, r.Banners[p]))
+		}
+		run.Hosts = []nmapHost{h}
+	}
+	end := time.Now()
+	run.RunStats.Finished.Time, run.RunStats.Finished.TimeStr = end.Unix(), end.Format(time.ANSIC)
+	run.RunStats.Hosts.Up, run.RunStats.Hosts.Total = len(run.Hosts), len(run.Hosts)
+	return run
+}
+
+// === POST-SCAN HOOK ===
+// runHook runs the -on-complete command via sh -c once results are saved.
+// It runs with the operator's full privileges and the string is passed to
+// the shell verbatim, so it must never be assembled from untrusted input
+// (target names, downloaded lists, shared config files). Output paths are
+// handed over in CEARTAX_* environment variables rather than spliced
+// into the command line.
+func (c *Ceartax) runHook(cmdline string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdline)
+	jsonPath, htmlPath := c.reportPaths()
+	cmd.Env = append(os.Environ(),
+		"CEARTAX_SCAN_ID="+c.scanID,
+		"CEARTAX_TARGET="+c.target,
+	)
+	if c.output != "" && c.output != stdoutPath {
+		for _, f := range []struct{ name, path string }{
+			{"json", jsonPath}, {"html", htmlPath}, {"txt", c.reportPath(".txt")}, {"csv", c.reportPath(".csv")},
+			{"nmap-xml", c.reportPath(".xml")},
+		} {
+			if c.formats[f.name] {
+				cmd.Env = append(cmd.Env, "CEARTAX_"+strings.ToUpper(strings.ReplaceAll(f.name, "-", "_"))+"="+f.path)
+			}
+		}
+	}
+	if c.splitDir != "" {
+		cmd.Env = append(cmd.Env, "CEARTAX_SPLIT_DIR="+c.outPath(c.splitDir))
+	}
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if line != "" {
+			log.Printf("[on-complete] %s", line)
+		}
+	}
+	if err != nil {
+		log.Printf("[on-complete] gagal: %v", err)
+	}
+}
+
 // === MAIN ===
+// readLines loads a list file, skipping blank lines and # comments.
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if l, ok := listLine(sc.Text()); ok {
+			out = append(out, l)
+		}
+	}
+	return out, sc.Err()
+}
+
+// listLine applies the list file rules to one line.
+func listLine(s string) (string, bool) {
+	l := strings.TrimSpace(s)
+	return l, l != "" && !strings.HasPrefix(l, "#")
+}
+
+// fetchList downloads an http(s) list once, through the scan proxy but
+// with our own UA rather than a rotated one, and caches it in a temp file
+// so the normal file loaders can read it. HTML or binary bodies are
+// rejected: a login or error page is not a wordlist.
+func fetchList(rawURL, proxyURL string) (string, error) {
+	tr := &http.Transport{}
+	if err := useProxy(tr, proxyURL); err != nil {
+		return "", err
+	}
+	client := &http.Client{Transport: tr, Timeout: 60 * time.Second}
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Ceartax/2.3")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if ct := http.DetectContentType(data); !strings.HasPrefix(ct, "text/plain") || !utf8.Valid(data) {
+		return "", fmt.Errorf("%s: bukan file teks (%s)", rawURL, ct)
+	}
+	f, err := os.CreateTemp("", "ceartax-list-*.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// parsePortSpec parses "22,80,8000-8100" into sorted, unique ports. A port
+// listed twice (or inside a range) is scanned once, but two ranges that
+// overlap are rejected as a likely typo.
+func parsePortSpec(spec string) ([]int, error) {
+	var out []int
+	var ranges [][2]int
+	seen := make(map[int]bool)
+	for _, part := range splitList(spec) {
+		lo, hi, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("port tidak valid: %q", part)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("port tidak valid: %q", part)
+			}
+		}
+		if a < 1 || b > 65535 || a > b {
+			return nil, fmt.Errorf("range port tidak valid: %q", part)
+		}
+		if isRange {
+			for _, r := range ranges {
+				if a <= r[1] && r[0] <= b {
+					return nil, fmt.Errorf("range port %q tumpang tindih dengan %d-%d", part, r[0], r[1])
+				}
+			}
+			ranges = append(ranges, [2]int{a, b})
+		}
+		for p := a; p <= b; p++ {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// topPorts is nmap's top-1000 TCP list (by frequency in nmap-services),
+// for -top-ports.
+const topPorts = "" +
+	"1,3-4,6-7,9,13,17,19-26,30,32-33,37,42-43,49,53,70,79-85,88-90," +
+	"99-100,106,109-111,113,119,125,135,139,143-144,146,161,163,179,199," +
+	"211-212,222,254-256,259,264,280,301,306,311,340,366,389,406-407," +
+	"416-417,425,427,443-445,458,464-465,481,497,500,512-515,524,541," +
+	"543-545,548,554-555,563,587,593,616-617,625,631,636,646,648,666-668," +
+	"683,687,691,700,705,711,714,720,722,726,749,765,777,783,787,800-801," +
+	"808,843,873,880,888,898,900-903,911-912,981,987,990,992-993,995," +
+	"999-1002,1007,1009-1011,1021-1100,1102,1104-1108,1110-1114,1117," +
+	"1119,1121-1124,1126,1130-1132,1137-1138,1141,1145,1147-1149," +
+	"1151-1152,1154,1163-1166,1169,1174-1175,1183,1185-1187,1192," +
+	"1198-1199,1201,1213,1216-1218,1233-1234,1236,1244,1247-1248,1259," +
+	"1271-1272,1277,1287,1296,1300-1301,1309-1311,1322,1328,1334,1352," +
+	"1417,1433-1434,1443,1455,1461,1494,1500-1501,1503,1521,1524,1533," +
+	"1556,1580,1583,1594,1600,1641,1658,1666,1687-1688,1700,1717-1721," +
+	"1723,1755,1761,1782-1783,1801,1805,1812,1839-1840,1862-1864,1875," +
+	"1900,1914,1935,1947,1971-1972,1974,1984,1998-2010,2013,2020-2022," +
+	"2030,2033-2035,2038,2040-2043,2045-2049,2065,2068,2099-2100,2103," +
+	"2105-2107,2111,2119,2121,2126,2135,2144,2160-2161,2170,2179," +
+	"2190-2191,2196,2200,2222,2251,2260,2288,2301,2323,2366,2381-2383," +
+	"2393-2394,2399,2401,2492,2500,2522,2525,2557,2601-2602,2604-2605," +
+	"2607-2608,2638,2701-2702,2710,2717-2718,2725,2800,2809,2811,2869," +
+	"2875,2909-2910,2920,2967-2968,2998,3000-3001,3003,3005-3007,3011," +
+	"3013,3017,3030-3031,3052,3071,3077,3128,3168,3211,3221,3260-3261," +
+	"3268-3269,3283,3300-3301,3306,3322-3325,3333,3351,3367,3369-3372," +
+	"3389-3390,3404,3476,3493,3517,3527,3546,3551,3580,3659,3689-3690," +
+	"3703,3737,3766,3784,3800-3801,3809,3814,3826-3828,3851,3869,3871," +
+	"3878,3880,3889,3905,3914,3918,3920,3945,3971,3986,3995,3998," +
+	"4000-4006,4045,4111,4125-4126,4129,4224,4242,4279,4321,4343," +
+	"4443-4446,4449,4550,4567,4662,4848,4899-4900,4998,5000-5004,5009," +
+	"5030,5033,5050-5051,5054,5060-5061,5080,5087,5100-5102,5120,5190," +
+	"5200,5214,5221-5222,5225-5226,5269,5280,5298,5357,5405,5414," +
+	"5431-5432,5440,5500,5510,5544,5550,5555,5560,5566,5631,5633,5666," +
+	"5678-5679,5718,5730,5800-5802,5810-5811,5815,5822,5825,5850,5859," +
+	"5862,5877,5900-5904,5906-5907,5910-5911,5915,5922,5925,5950,5952," +
+	"5959-5963,5987-5989,5998-6007,6009,6025,6059,6100-6101,6106,6112," +
+	"6123,6129,6156,6346,6389,6502,6510,6543,6547,6565-6567,6580,6646," +
+	"6666-6669,6689,6692,6699,6779,6788-6789,6792,6839,6881,6901,6969," +
+	"7000-7002,7004,7007,7019,7025,7070,7100,7103,7106,7200-7201,7402," +
+	"7435,7443,7496,7512,7625,7627,7676,7741,7777-7778,7800,7911," +
+	"7920-7921,7937-7938,7999-8002,8007-8011,8021-8022,8031,8042,8045," +
+	"8080-8090,8093,8099-8100,8180-8181,8192-8194,8200,8222,8254," +
+	"8290-8292,8300,8333,8383,8400,8402,8443,8500,8600,8649,8651-8652," +
+	"8654,8701,8800,8873,8888,8899,8994,9000-9003,9009-9011,9040,9050," +
+	"9071,9080-9081,9090-9091,9099-9103,9110-9111,9200,9207,9220,9290," +
+	"9415,9418,9485,9500,9502-9503,9535,9575,9593-9595,9618,9666," +
+	"9876-9878,9898,9900,9917,9929,9943-9944,9968,9998-10004,10009-10010," +
+	"10012,10024-10025,10082,10180,10215,10243,10566,10616-10617,10621," +
+	"10626,10628-10629,10778,11110-11111,11967,12000,12174,12265,12345," +
+	"13456,13722,13782-13783,14000,14238,14441-14442,15000,15002-15004," +
+	"15660,15742,16000-16001,16012,16016,16018,16080,16113,16992-16993," +
+	"17877,17988,18040,18101,18988,19101,19283,19315,19350,19780,19801," +
+	"19842,20000,20005,20031,20221-20222,20828,21571,22939,23502,24444," +
+	"24800,25734-25735,26214,27000,27352-27353,27355-27356,27715,28201," +
+	"30000,30718,30951,31038,31337,32768-32785,33354,33899,34571-34573," +
+	"35500,38292,40193,40911,41511,42510,44176,44442-44443,44501,45100," +
+	"48080,49152-49161,49163,49165,49167,49175-49176,49400,49999-50003," +
+	"50006,50300,50389,50500,50636,50800,51103,51493,52673,52822,52848," +
+	"52869,54045,54328,55055-55056,55555,55600,56737-56738,57294,57797," +
+	"58080,60020,60443,61532,61900,62078,63331,64623,64680,65000,65129," +
+	"65389"
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, f := range splitList(s) {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("bukan angka: %q", f)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// applyConfigFile sets each flag that was not given on the command line
+// from a YAML map keyed by flag name, so the file and the CLI can never
+// drift apart. Lists become comma-separated values. Unknown keys are
+// reported and skipped rather than silently ignored.
+func applyConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	onCLI := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { onCLI[f.Name] = true })
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := strings.TrimLeft(k, "-")
+		if name == "target" {
+			name = "url"
+		}
+		if name == "config" || flag.Lookup(name) == nil {
+			log.Printf("-config: key %q tidak dikenal, diabaikan", k)
+			continue
+		}
+		if onCLI[name] {
+			continue
+		}
+		// Repeatable flags get one Set per list item; values may hold commas.
+		if list, ok := doc[k].([]any); ok {
+			if _, rep := flag.Lookup(name).Value.(headerFlag); rep {
+				for _, v := range list {
+					if err := flag.Set(name, fmt.Sprint(v)); err != nil {
+						return fmt.Errorf("%s: %w", k, err)
+					}
+				}
+				continue
+			}
+		}
+		if err := flag.Set(name, configValue(doc[k])); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func configValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = fmt.Sprint(x)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// hostOf accepts "example.com", "example.com:8443" or a full URL.
+func hostOf(raw string) string {
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Hostname(), ".")
+}
+
+// maxRangeHosts caps CIDR expansion; a /16 (or an IPv6 /112) is the
+// largest range accepted.
+const maxRangeHosts = 1 << 16
+
+// rangeHosts expands a CIDR target into its addresses. The IPv4 network
+// and broadcast addresses are left out for prefixes shorter than /31.
+func rangeHosts(p netip.Prefix) ([]string, error) {
+	p = p.Masked()
+	free := p.Addr().BitLen() - p.Bits()
+	if free > 16 {
+		return nil, fmt.Errorf("%s has 2^%d addresses, the limit is %d", p, free, maxRangeHosts)
+	}
+	out := make([]string, 0, 1<<free)
+	for a := p.Addr(); a.IsValid() && p.Contains(a); a = a.Next() {
+		out = append(out, a.String())
+	}
+	if p.Addr().Is4() && free >= 2 {
+		out = out[1 : len(out)-1]
+	}
+	return out, nil
+}
+
 func main() {
-	target := flag.String("url", "", "Target")
-	output := flag.String("output", "recon.json", "Output")
-	proxyStr := flag.String("proxy", "", "Proxy")
+	target := flag.String("url", "", "Target host or URL; a CIDR range (10.0.0.0/28, 2001:db8::/120) runs only the port scan, one result per address")
+	targetsFile := flag.String("targets-file", "", "File (or URL) with one target per line, scanned one after another")
+	output := flag.String("output", "recon.json", "Output (empty to skip JSON/HTML; - for JSON on stdout, best with -headless)")
+	modulesFlag := flag.String("modules", "", "Comma-separated modules to run (default all): "+strings.Join(moduleNames(), ","))
+	formats := flag.String("formats", "json,html", "Report formats written at -output: json, html, txt, csv, nmap-xml (.xml)")
+	splitOut := flag.String("split-output", "", "Dir for per-module .txt files")
+	certOut := flag.String("cert-out", "", "Write the TLS certificate chain of each host as PEM into this dir")
+	screenshots := flag.Bool("screenshots", false, "Screenshot every web service found with headless Chrome/Chromium into <output>/screenshots")
+	chromePath := flag.String("chrome-path", "", "Browser for -screenshots (default: chromium, google-chrome, ... on PATH)")
+	outputDir := flag.String("output-dir", "", "Put every relative output path under this dir (created if missing)")
+	perTargetDir := flag.String("per-target-dir", "", "Put outputs under DIR/<target>/")
+	timestamp := flag.Bool("timestamp", false, "Append the scan start time to output names so repeat scans don't overwrite")
+	assetsOut := flag.String("assets-out", "", "Write a normalized asset inventory (JSON) here")
+	sarifOut := flag.String("sarif-out", "", "Write findings as SARIF 2.1.0 here")
+	benchOut := flag.String("bench-output", "", "Write the module benchmarks and run totals as JSON here")
+	webhookURL := flag.String("webhook-url", "", "POST a JSON summary here when the scan is saved")
+	webhookFormat := flag.String("webhook-format", "json", "Webhook body: json or slack ({\"text\": ...})")
+	esURL := flag.String("es-url", "", "Index findings into Elasticsearch via _bulk (e.g. http://localhost:9200)")
+	esFile := flag.String("es-file", "", "Write findings as an Elasticsearch _bulk file")
+	esIndex := flag.String("es-index", "ceartax-{date}", "Elasticsearch index name ({date} = YYYY.MM.DD)")
+	batchSize := flag.Int("batch-size", streamBatch, "Findings per batch for streaming outputs")
+	flushEvery := flag.Duration("flush-interval", streamFlush, "Send a partial streaming batch after this long")
+	streamURL := flag.String("stream-url", "", "POST findings as NDJSON to this URL while scanning")
+	streamFile := flag.String("stream-file", "", "Append findings to this file as NDJSON (one line per finding, written as found)")
+	proxyStr := flag.String("proxy", "", "Proxy URL (socks5://, http:// or https://)")
 	uaFile := flag.String("ua-file", "", "UA file")
+	proxyFile := flag.String("proxy-file", "", "File or URL with one proxy URL per line; each request picks one at random")
+	recurseDepth := flag.Int("recurse-depth", 1, "Subdomain brute-force rounds; 2 also tries the wordlist under every host found (capped lookups)")
+	subWordlist := flag.String("sub-wordlist", "", "Subdomain wordlist, one label per line (path or URL); default is a small built-in list")
 	timeout := flag.Duration("timeout", 10*time.Second, "Timeout")
+	pageTimeout := flag.Duration("page-timeout", 10*time.Second, "Max time to fetch one page body; the HTTP client -timeout still caps it")
+	pageMax := flag.Int64("page-max-bytes", defaultPageMax, "Max body bytes read per page")
+	dialTimeout := flag.Duration("dial-timeout", 1*time.Second, "Port dial timeout")
+	readBuf := flag.Int("read-buffer", 0, "Banner read buffer in bytes, also set as the socket's SO_RCVBUF (0 = 1KB, OS default)")
+	portBatch := flag.Int("port-batch", 50, "Ports dialed concurrently per batch")
+	rps := flag.Float64("rps", 0, "Max requests per second across all modules, HTTP and dials alike (0 = off, random 1-2s delay per probe)")
+	concurrency := flag.Int("concurrency", defaultConcurrency, fmt.Sprintf("Parallel workers per module and max requests in flight (1-%d)", maxConcurrency))
+	timeClosed := flag.Bool("connect-timing-closed", false, "Also record connect time for refused (closed) ports")
+	ipv4 := flag.Bool("4", false, "Connect over IPv4 only")
+	ipv6 := flag.Bool("6", false, "Connect over IPv6 only")
+	verbose := flag.Bool("v", false, "Log errors modules skip over (to stderr with -headless, else the log panel)")
+	debug := flag.Bool("vv", false, "Like -v, plus every request: URL, UA, proxy, status and timing")
+	headless := flag.Bool("headless", false, "No TUI: log progress as plain lines to stderr and write the outputs (exit 1 if no module completed)")
+	inline := flag.Bool("inline", false, "Render the TUI in place instead of the alt-screen, keeping the summary in scrollback")
+	onComplete := flag.String("on-complete", "", "Shell command to run after the scan (paths in $CEARTAX_JSON etc.); runs with your privileges")
+	hookTimeout := flag.Duration("on-complete-timeout", time.Minute, "Kill the -on-complete command after this long")
+	force := flag.Bool("force", false, "Scan even if the target does not resolve")
+	dnsServers := flag.String("dns-servers", "", "Comma-separated resolvers to round-robin (host[:port])")
+	dnsTimeout := flag.Duration("dns-timeout", 0, "Per-query DNS timeout (0 = resolver default)")
+	abortOn := flag.Int("abort-on-findings", 0, "Stop a module after N findings (catch-all targets), 0 = off")
+	maxRetries := flag.Int("max-retries", 3, "Retries for transient HTTP failures (resets, timeouts, 5xx) with exponential backoff")
+	maxFails := flag.Int("max-consecutive-failures", 0, "Give up on a host after N connection errors/timeouts in a row, 0 = off")
+	aliveExclude := flag.String("alive-exclude-codes", "", "Status codes that don't count as a live subdomain, e.g. 404,503")
+	dirRecurse := flag.Bool("recurse", false, "Re-run the directory list under every directory found, for -dir-recurse-depth levels")
+	dirRecurseDepth := flag.Int("dir-recurse-depth", 2, "Directory levels probed with -recurse, 1 = the top level only")
+	extensions := flag.String("extensions", "", "Also try each directory candidate with these extensions, e.g. php,bak,zip,old")
+	matchCodes := flag.String("match-codes", "", "Status codes that make a probed directory a finding, e.g. 200,204,301,403 (default: below 400)")
+	headers := headerFlag{http.Header{}}
+	flag.Var(headers, "header", "Extra request header \"Name: Value\" for the target and its subdomains (repeatable; overrides the UA rotation)")
+	cookie := flag.String("cookie", "", "Cookie header for the target and its subdomains, e.g. \"session=abc; lang=en\"")
+	acceptLang := flag.String("accept-language", "", "Accept-Language for the target and its subdomains, e.g. de-DE,de;q=0.9 (other locale headers: -header, -cookie)")
+	tagRequests := flag.Bool("tag-requests", false, "Send a unique request ID header with every HTTP request (for cooperative log correlation)")
+	tagName := flag.String("tag-header", "X-Request-ID", "Header name used by -tag-requests")
+	basePath := flag.String("base-path", "", "Path prefix for target probes, e.g. /api/v2 for a sub-mounted app")
+	excludeSubs := flag.String("exclude-subdomains", "", "File of subdomains to skip (known or out of scope)")
+	portSpec := flag.String("ports", "", "Ports to scan, e.g. 1-1024,3306,8080-8090 (default 80,443,22)")
+	topPortsOn := flag.Bool("top-ports", false, "Scan nmap's top 1000 TCP ports (combined with -ports)")
+	excludePorts := flag.String("exclude-ports", "", "Ports to skip, e.g. 22,8000-8100")
+	moduleDelay := flag.Duration("inter-module-delay", 0, "Wait between starting successive modules")
+	moduleTimeout := flag.Duration("module-timeout", 0, "Stop a module after this long and keep its partial results (0 = no limit)")
+	respectRobots := flag.Bool("respect-robots", false, "Skip directory candidates that robots.txt disallows for *")
+	grepExpr := flag.String("grep", "", "Regex searched for in bodies fetched by Fingerprint and Directories (read up to -page-max-bytes)")
+	methodFuzz := flag.Bool("method-fuzz", false, "Compare GET on / with OPTIONS/TRACE/PUT/invalid methods")
+	lbDetect := flag.Bool("lb-detect", false, "Detect load balancing from variance across repeated requests")
+	lbSamples := flag.Int("lb-samples", 6, "Requests sent by -lb-detect")
+	rawBytes := flag.Bool("raw-bytes", false, "Keep non-UTF-8/control bytes in headers as base64 instead of escaping")
+	listOnly := flag.Bool("list-only", false, "Only enumerate subdomains and print resolvable hosts (no probing)")
+	listOut := flag.String("list-out", "", "Write the -list-only host list here instead of stdout")
+	listIPs := flag.Bool("list-ips", false, "Add resolved IPs to -list-only output")
+	benchOnly := flag.Bool("bench-only", false, "Print module benchmarks as JSON to stdout, no findings; always the built-in wordlists and ports")
+	dryRun := flag.Bool("dry-run", false, "Print what each selected module would send, then exit without any network traffic")
+	configFile := flag.String("config", "", "YAML file of flag values (keys are flag names, target = url); command-line flags win")
 	flag.Parse()
+	if *configFile != "" {
+		if err := applyConfigFile(*configFile); err != nil {
+			log.Fatalf("-config: %v", err)
+		}
+	}
+
+	if (*target == "" && *targetsFile == "") || *uaFile == "" {
+		log.Fatal("Gunakan: -url target.com (atau -targets-file hosts.txt) -ua-file ua.txt")
+	}
+
+	network := "tcp"
+	switch {
+	case *ipv4 && *ipv6:
+		log.Fatal("-4 dan -6 tidak bisa dipakai bersamaan")
+	case *ipv4:
+		network = "tcp4"
+	case *ipv6:
+		network = "tcp6"
+	}
+
+	if *webhookFormat != "json" && *webhookFormat != "slack" {
+		log.Fatalf("-webhook-format: %q tidak dikenal (json, slack)", *webhookFormat)
+	}
+
+	runModules := splitList(strings.ToLower(*modulesFlag))
+	for _, m := range runModules {
+		if !slices.Contains(moduleNames(), m) {
+			log.Fatalf("-modules: modul %q tidak dikenal (%s)", m, strings.Join(moduleNames(), ", "))
+		}
+	}
+
+	// -bench-only measures the built-in workload (defaultSubWords,
+	// dirPaths, ports 80,443,22, no recursion) so runs stay comparable;
+	// flags that would change it are refused rather than ignored.
+	if *benchOnly {
+		var workload []string
+		flag.Visit(func(f *flag.Flag) {
+			if benchWorkloadFlags[f.Name] {
+				workload = append(workload, "-"+f.Name)
+			}
+		})
+		if len(workload) > 0 {
+			log.Fatalf("-bench-only memakai workload bawaan, tidak bisa digabung dengan %s", strings.Join(workload, ", "))
+		}
+	}
+
+	// -output recon.csv alone means CSV; an explicit -formats still wins.
+	outFormats := splitList(*formats)
+	formatsSet := false
+	flag.Visit(func(f *flag.Flag) { formatsSet = formatsSet || f.Name == "formats" })
+	if !formatsSet && strings.EqualFold(filepath.Ext(*output), ".csv") {
+		outFormats = []string{"csv"}
+	}
+	for _, f := range outFormats {
+		if f != "json" && f != "html" && f != "txt" && f != "csv" && f != "nmap-xml" {
+			log.Fatalf("-formats: format %q tidak dikenal (json, html, txt, csv, nmap-xml)", f)
+		}
+	}
+
+	var grep *regexp.Regexp
+	if *grepExpr != "" {
+		var err error
+		if grep, err = regexp.Compile(*grepExpr); err != nil {
+			log.Fatalf("-grep: %v", err)
+		}
+	}
+
+	var chrome string
+	if *screenshots {
+		var err error
+		if chrome, err = findChrome(*chromePath); err != nil {
+			log.Printf("-screenshots: browser tidak ditemukan (%v), screenshot dilewati", err)
+		}
+	}
+
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			log.Fatalf("-output-dir: %v", err)
+		}
+	}
+
+	if *cookie != "" {
+		if prev := headers.h.Get("Cookie"); prev != "" {
+			headers.h.Set("Cookie", prev+"; "+*cookie)
+		} else {
+			headers.h.Set("Cookie", *cookie)
+		}
+	}
+
+	excludeCodes, err := parseInts(*aliveExclude)
+	if err != nil {
+		log.Fatalf("-alive-exclude-codes: %v", err)
+	}
+	dirCodes, err := parseInts(*matchCodes)
+	if err != nil {
+		log.Fatalf("-match-codes: %v", err)
+	}
+	var dirExts []string
+	for _, ext := range splitList(*extensions) {
+		if ext = strings.TrimPrefix(ext, "."); ext != "" && !slices.Contains(dirExts, ext) {
+			dirExts = append(dirExts, ext)
+		}
+	}
+
+	// List flags may point at http(s) URLs; fetch those once up front.
+	var tempLists []string
+	defer func() {
+		for _, f := range tempLists {
+			if err := os.Remove(f); err != nil {
+				log.Print(err)
+			}
+		}
+	}()
+	for _, list := range []*string{uaFile, proxyFile, excludeSubs, subWordlist, targetsFile} {
+		if !strings.HasPrefix(*list, "http://") && !strings.HasPrefix(*list, "https://") {
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("Would download %s (not fetched in -dry-run, treated as empty)\n", *list)
+			*list = ""
+			continue
+		}
+		path, err := fetchList(*list, *proxyStr)
+		if err != nil {
+			log.Fatalf("Gagal download list: %v", err)
+		}
+		tempLists = append(tempLists, path)
+		*list = path
+	}
+
+	var targets []string
+	if *target != "" {
+		targets = append(targets, *target)
+	}
+	if *targetsFile != "" {
+		more, err := readLines(*targetsFile)
+		if err != nil {
+			log.Fatalf("-targets-file: %v", err)
+		}
+		targets = append(targets, more...)
+	}
+	// A URL list is not fetched in -dry-run, so it is empty by design.
+	if len(targets) == 0 && !*dryRun {
+		log.Fatalf("-targets-file: %s kosong dan -url tidak diisi", *targetsFile)
+	}
+
+	var skipHosts []string
+	if *excludeSubs != "" {
+		if skipHosts, err = readLines(*excludeSubs); err != nil {
+			log.Fatalf("-exclude-subdomains: %v", err)
+		}
+	}
+	skipPorts, err := parsePortSpec(*excludePorts)
+	if err != nil {
+		log.Fatalf("-exclude-ports: %v", err)
+	}
+	scanPorts, err := parsePortSpec(*portSpec)
+	if err != nil {
+		log.Fatalf("-ports: %v", err)
+	}
+	if *topPortsOn {
+		top, _ := parsePortSpec(topPorts)
+		listed := scanPorts
+		for _, p := range top {
+			if _, dup := slices.BinarySearch(listed, p); !dup {
+				scanPorts = append(scanPorts, p)
+			}
+		}
+		sort.Ints(scanPorts)
+	}
+
+	lbSampleCount := 0
+	if *lbDetect {
+		lbSampleCount = max(*lbSamples, 2)
+	}
+
+	tagHeader := ""
+	if *tagRequests {
+		tagHeader = *tagName
+	}
+
+	cfg := Config{
+		ProxyURL:  *proxyStr,
+		ProxyFile: *proxyFile,
+		UAFile:    *uaFile,
+
+		SubWordlist:  *subWordlist,
+		RecurseDepth: *recurseDepth,
+		Timeout:      *timeout,
+
+		Output:        *output,
+		Formats:       outFormats,
+		SplitDir:      *splitOut,
+		StreamURL:     *streamURL,
+		StreamFile:    *streamFile,
+		ESURL:         *esURL,
+		ESFile:        *esFile,
+		ESIndex:       *esIndex,
+		BatchSize:     *batchSize,
+		FlushInterval: *flushEvery,
+		AssetsOut:     *assetsOut,
+		SARIFOut:      *sarifOut,
+		BenchOut:      *benchOut,
+		WebhookURL:    *webhookURL,
+		WebhookFormat: *webhookFormat,
+		CertDir:       *certOut,
+		ChromePath:    chrome,
+		OutputDir:     *outputDir,
+		PerTargetDir:  *perTargetDir,
+		Timestamp:     *timestamp,
+		RawBytes:      *rawBytes,
+
+		PageTimeout:    *pageTimeout,
+		PageMaxBytes:   *pageMax,
+		AcceptLanguage: *acceptLang,
+		Headers:        headers.h,
+		BasePath:       *basePath,
+		TagHeader:      tagHeader,
+		MethodFuzz:     *methodFuzz,
+		RespectRobots:  *respectRobots,
+		Grep:           grep,
+		LBSamples:      lbSampleCount,
+
+		Modules:          runModules,
+		BenchOnly:        *benchOnly,
+		DryRun:           *dryRun,
+		ListOnly:         *listOnly,
+		InterModuleDelay: *moduleDelay,
+		ModuleTimeout:    *moduleTimeout,
+		AbortOnFindings:  *abortOn,
+		MaxFailures:      *maxFails,
+		MaxRetries:       *maxRetries,
+		AliveExclude:     excludeCodes,
+		MatchCodes:       dirCodes,
+		Extensions:       dirExts,
+		DirRecurse:       *dirRecurse,
+		DirRecurseDepth:  *dirRecurseDepth,
+		DNSServers:       splitList(*dnsServers),
+		DNSTimeout:       *dnsTimeout,
+
+		ExcludeHosts: skipHosts,
+		ExcludePorts: skipPorts,
+
+		Ports: scanPorts,
+
+		Network: network,
+
+		DialTimeout: *dialTimeout,
+		ReadBuffer:  *readBuf,
+		TimeClosed:  *timeClosed,
+		PortBatch:   *portBatch,
+		Concurrency: *concurrency,
+		RPS:         *rps,
 
-	if *target == "" || *uaFile == "" {
-		log.Fatal("Gunakan: -url target.com -ua-file ua.txt")
+		LogLevel: slog.LevelWarn,
+
+		MultiTarget: len(targets) > 1,
 	}
 
-	u, _ := url.Parse(*target)
-	clean := strings.TrimSuffix(u.Hostname(), ".")
+	switch {
+	case *debug:
+		cfg.LogLevel = slog.LevelDebug
+	case *verbose:
+		cfg.LogLevel = slog.LevelInfo
+	}
+	if *headless || *listOnly || *benchOnly {
+		cfg.LogOut = os.Stderr // no TUI, so no log panel
+	}
 
-	ceartax := NewCeartax(clean, *proxyStr, *uaFile, *output, *timeout)
+	listW := io.Writer(os.Stdout)
+	if *listOnly && *listOut != "" && !*dryRun {
+		f, err := createFile(*listOut)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		listW = f
+	}
 
-	p := tea.NewProgram(initialModel(ceartax), tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
-		log.Fatal(err)
+	// The alt-screen (and mouse capture) is dropped with -inline so the final
+	// summary stays in scrollback and can be selected.
+	var opts []tea.ProgramOption
+	if !*inline {
+		opts = append(opts, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+	// With -output - stdout carries the JSON, so the TUI draws on stderr.
+	if *output == stdoutPath {
+		opts = append(opts, tea.WithOutput(os.Stderr))
 	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT)
-	<-c
+	// SIGINT and SIGTERM interrupt the running scan, which then saves what
+	// it has. The TUI reads Ctrl+C as a key in raw mode, so only signals
+	// sent from outside get here; bubbletea must not handle them itself.
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	opts = append(opts, tea.WithoutSignalHandler())
+
+	// One Ceartax per target, run one after another. A target that does
+	// not resolve ends a single-target run but is only skipped in a sweep.
+	// The check asks the same -dns-servers the scan will.
+	precheck := newResolverPool(cfg.DNSServers, cfg.DNSTimeout)
+	nothingDone := false
+	for i, t := range targets {
+		if sigCtx.Err() != nil {
+			break
+		}
+		clean := hostOf(t)
+		cfg.RangeHosts = nil
+		if prefix, err := netip.ParsePrefix(t); err == nil {
+			if cfg.RangeHosts, err = rangeHosts(prefix); err != nil {
+				fmt.Fprintf(os.Stderr, "Target %q terlalu besar: %v\n", t, err)
+				if len(targets) == 1 {
+					os.Exit(2)
+				}
+				continue
+			}
+			clean = prefix.Masked().String()
+		}
+		if !*force && !*dryRun && cfg.RangeHosts == nil {
+			if _, err := precheck.LookupHost(context.Background(), clean); err != nil {
+				fmt.Fprintf(os.Stderr, "Target %q tidak bisa di-resolve: %v (pakai -force untuk tetap scan)\n", clean, err)
+				if len(targets) == 1 {
+					os.Exit(2)
+				}
+				continue
+			}
+		}
+		cfg.Target = clean
+		cfg.Modules = runModules
+		if cfg.RangeHosts != nil {
+			// Only the port scan makes sense for an address range.
+			if len(runModules) > 0 && !slices.Contains(runModules, "ports") {
+				log.Printf("-modules: %s adalah range, hanya ports yang jalan", clean)
+			}
+			cfg.Modules = []string{"ports"}
+		}
+		ceartax, err := NewCeartax(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.SetPrefix("[" + ceartax.scanID + "] ")
+		// Interrupting a scan that already finished is a no-op; the
+		// registration is dropped once the target is done.
+		stopInterrupt := context.AfterFunc(sigCtx, ceartax.Interrupt)
+
+		switch {
+		case *dryRun:
+			ceartax.Plan(os.Stdout)
+		case *listOnly:
+			if err := ceartax.RunListOnly(listW, *listIPs); err != nil {
+				log.Fatal(err)
+			}
+		case *benchOnly:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(ceartax.RunBenchOnly()); err != nil {
+				log.Fatal(err)
+			}
+		default:
+			if *headless {
+				if !ceartax.RunHeadless() {
+					nothingDone = true
+				}
+			} else {
+				m := initialModel(ceartax)
+				m.stay = len(targets) == 1 // a sweep moves on to the next target
+				if len(targets) > 1 {
+					m.phase = fmt.Sprintf("Target %d/%d: %s", i+1, len(targets), clean)
+				} else {
+					m.phase = "Target: " + clean
+				}
+				p := tea.NewProgram(m, opts...)
+				// The results screen stays up after the scan, when
+				// Interrupt has nothing left to stop: tell the TUI.
+				stopNotify := context.AfterFunc(sigCtx, func() { p.Send(signalMsg{}) })
+				_, err := p.Run()
+				stopNotify()
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+			ceartax.flushStreams()
+			if err := ceartax.Err(); err != nil {
+				log.Printf("modul gagal: %v", err)
+			}
+			if *onComplete != "" {
+				ceartax.runHook(*onComplete, *hookTimeout)
+			}
+		}
+		stopInterrupt()
+		if ceartax.interrupted.Load() {
+			break
+		}
+	}
+	if *listOnly || *benchOnly || *dryRun {
+		return
+	}
+	if *headless && nothingDone {
+		os.Exit(1)
+	}
 }
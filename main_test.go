@@ -0,0 +1,711 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestCeartax builds a scanner that logs nowhere and drains the
+// channels the TUI would read, so modules can be called directly.
+func newTestCeartax(t testing.TB, cfg Config) *Ceartax {
+	t.Helper()
+	if cfg.LogOut == nil {
+		cfg.LogOut = io.Discard
+	}
+	c, err := NewCeartax(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c.chProg:
+			case <-c.chDone:
+			case <-c.chLog:
+			case <-c.chBench:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { close(stop); c.cancel() })
+	return c
+}
+
+// silentPorts opens n listeners that accept and never send, the slow
+// case for banner grabbing.
+func silentPorts(t testing.TB, n int) []int {
+	t.Helper()
+	var ports []int
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range conns {
+			c.Close()
+		}
+	})
+	for i := 0; i < n; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+			}
+		}()
+		ports = append(ports, ln.Addr().(*net.TCPAddr).Port)
+	}
+	return ports
+}
+
+// BenchmarkPorts compares the old one-port-at-a-time scan (batch=1) with
+// concurrent batches over eight silent open ports, each of which holds
+// its dial for the whole banner timeout. BenchOnly drops the random
+// inter-batch delay, which would otherwise dominate.
+func BenchmarkPorts(b *testing.B) {
+	ports := silentPorts(b, 8)
+	for _, batch := range []int{1, 64} {
+		b.Run("batch="+strconv.Itoa(batch), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c := newTestCeartax(b, Config{
+					Target: "127.0.0.1", Ports: ports, PortBatch: batch,
+					Concurrency: 64, DialTimeout: 50 * time.Millisecond, BenchOnly: true,
+				})
+				if err := c.Ports(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+				if len(c.result.OpenPorts) != len(ports) {
+					b.Fatalf("open ports = %v, want %v", c.result.OpenPorts, ports)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamEmitAfterWrite(t *testing.T) {
+	var lines atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		lines.Add(int64(bytes.Count(b, []byte("\n"))))
+	}))
+	defer srv.Close()
+	s := newHTTPStream(srv.URL, streamOpts{batch: 1}, http.DefaultTransport)
+	s.Emit(Finding{Type: "dir", Value: "/a"})
+	var r ReconResult
+	s.Write(&r, nil)
+	s.Emit(Finding{Type: "dir", Value: "/b"}) // must not send on the closed queue
+	s.Write(&r, nil)
+	if got := r.Streams["http"]; got.Delivered != 1 || lines.Load() != 1 {
+		t.Errorf("delivered %d, collector saw %d lines, want 1 and 1", got.Delivered, lines.Load())
+	}
+	if s.dropped.Load() != 1 {
+		t.Errorf("dropped = %d, want 1 (the late finding)", s.dropped.Load())
+	}
+}
+
+func TestCountingBodyCountsOnlyRead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 4096))
+	}))
+	defer srv.Close()
+	c := newTestCeartax(t, Config{Target: "127.0.0.1"})
+	for _, read := range []bool{false, true} {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		resp, err := c.do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read {
+			io.Copy(io.Discard, resp.Body)
+		}
+		resp.Body.Close()
+	}
+	if got := c.totalBytes.Load(); got != 4096 {
+		t.Errorf("totalBytes = %d, want 4096 (only the body that was read)", got)
+	}
+}
+
+func TestESStreamUndecodableReply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html>gateway says hi</html>")
+	}))
+	defer srv.Close()
+	s := newESStream(srv.URL, "ceartax", streamOpts{batch: 2}, http.DefaultTransport)
+	s.Emit(Finding{Type: "dir", Value: "/a"})
+	s.Emit(Finding{Type: "dir", Value: "/b"})
+	var r ReconResult
+	s.Write(&r, nil)
+	if got := r.Streams["elasticsearch"]; got.Delivered != 0 || got.Dropped != 2 {
+		t.Errorf("delivered %d dropped %d, want 0 and 2", got.Delivered, got.Dropped)
+	}
+}
+
+func TestSubWordsStreamsLargeFile(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("# header\n\n")
+	n := 0
+	for b.Len() < subStreamMin {
+		fmt.Fprintf(&b, "w%07d\n", n)
+		n++
+	}
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCeartax(t, Config{Target: "example.com"})
+	if err := c.loadSubWords(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.subWordlist != nil || c.subWordTotal() != n {
+		t.Fatalf("loaded %d words, total %d; want streamed with total %d", len(c.subWordlist), c.subWordTotal(), n)
+	}
+	src, err := c.subWords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	got := 0
+	for w, ok := src.Next(); ok; w, ok = src.Next() {
+		if w != fmt.Sprintf("w%07d", got) {
+			t.Fatalf("word %d = %q", got, w)
+		}
+		got++
+	}
+	if got != n {
+		t.Errorf("streamed %d words, want %d", got, n)
+	}
+}
+
+// Fingerprint stores header names lowercased; buildSARIF must look them
+// up that way or every security header reads as missing.
+func TestSARIFMissingHeadersLowercase(t *testing.T) {
+	r := &ReconResult{Target: "example.com", Headers: map[string][]string{
+		"strict-transport-security": {"max-age=63072000"},
+		"x-frame-options":           {"DENY"},
+		"server":                    {"nginx"},
+	}}
+	var missing []string
+	for _, res := range buildSARIF(r).Runs[0].Results {
+		if res.RuleID == "missing-header" {
+			missing = append(missing, res.Message.Text)
+		}
+	}
+	want := []string{"Content-Security-Policy is not set", "X-Content-Type-Options is not set"}
+	if !slices.Equal(missing, want) {
+		t.Errorf("missing-header results = %q, want %q", missing, want)
+	}
+}
+
+// A server that reads queries and never answers must cost one
+// -dns-timeout per attempt, not the resolver's own retry schedule.
+func TestResolverQueryTimeout(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			if _, _, err := pc.ReadFrom(buf); err != nil {
+				return
+			}
+		}
+	}()
+	const timeout = 200 * time.Millisecond
+	p := newResolverPool([]string{pc.LocalAddr().String()}, timeout)
+	start := time.Now()
+	_, err = p.LookupHost(context.Background(), "ceartax.invalid")
+	if err == nil {
+		t.Fatal("lookup against a silent server succeeded")
+	}
+	if d := time.Since(start); d > 2*timeout {
+		t.Errorf("lookup took %v, want about %v", d, timeout)
+	}
+}
+
+func TestLoadSubWords(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("# subdomain labels\n\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "  word%d \n", i)
+		if i%10 == 0 {
+			b.WriteString("\n# section\n")
+		}
+	}
+	path := filepath.Join(t.TempDir(), "subs.txt")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCeartax(t, Config{Target: "example.com"})
+	if err := c.loadSubWords(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.subWordlist) != 100 {
+		t.Fatalf("loaded %d words, want 100", len(c.subWordlist))
+	}
+	for i, w := range c.subWordlist {
+		if want := "word" + strconv.Itoa(i); w != want {
+			t.Fatalf("word %d = %q, want %q", i, w, want)
+		}
+	}
+}
+
+// A bad -proxy comes back from NewCeartax like any other setup error
+// instead of exiting the process.
+func TestNewCeartaxBadProxy(t *testing.T) {
+	for _, p := range []string{"ftp://proxy.example:21", "http://"} {
+		_, err := NewCeartax(Config{Target: "example.com", ProxyURL: p, LogOut: io.Discard})
+		if err == nil || !strings.HasPrefix(err.Error(), "-proxy: ") {
+			t.Errorf("ProxyURL %q: err = %v, want a -proxy error", p, err)
+		}
+	}
+}
+
+// With one worker the queue must still drain: every candidate probed
+// once and Dirs returning, not parked in pop.
+func TestDirsSingleWorker(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path]++
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	c := newTestCeartax(t, Config{Target: srv.Listener.Addr().String(), Concurrency: 1})
+	errc := make(chan error, 1)
+	go func() { errc <- c.Dirs(context.Background()) }()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Dirs did not return")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, d := range c.dirCandidates() {
+		if n := seen["/"+d]; n != 1 {
+			t.Errorf("%s probed %d times, want 1", d, n)
+		}
+	}
+}
+
+func TestDirsRespectRobotsFailure(t *testing.T) {
+	for _, tc := range []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusForbidden, true},
+	} {
+		var probes atomic.Int64
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(tc.status)
+				return
+			}
+			probes.Add(1)
+			http.NotFound(w, r)
+		}))
+		c := newTestCeartax(t, Config{Target: srv.Listener.Addr().String(), RespectRobots: true})
+		err := c.Dirs(context.Background())
+		srv.Close()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("robots.txt %d: Dirs err = %v, want error %v", tc.status, err, tc.wantErr)
+		}
+		if tc.wantErr && probes.Load() != 0 {
+			t.Errorf("robots.txt %d: %d candidates probed, want none", tc.status, probes.Load())
+		}
+	}
+}
+
+// newTestModel is a TUI model on a scanner whose channels nobody else
+// reads, so a test can feed them and drive Update by hand.
+func newTestModel(t *testing.T) model {
+	t.Helper()
+	c, err := NewCeartax(Config{Target: "example.com", LogOut: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.cancel)
+	return initialModel(c)
+}
+
+func TestViewShowsProgress(t *testing.T) {
+	m := newTestModel(t)
+	for _, p := range []progressMsg{{module: "sub", value: 0.5}, {module: "ports", value: 0.25}} {
+		next, _ := m.Update(p)
+		m = next.(model)
+	}
+	if got := m.progress["sub"].Percent(); got != 0.5 {
+		t.Errorf("sub bar at %v, want 0.5", got)
+	}
+	view := m.View()
+	for _, want := range []string{"Subdomains:", " 50%", "Ports:", " 25%"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() lacks %q:\n%s", want, view)
+		}
+	}
+}
+
+// TestUpdateResubscribes runs the commands Update returns the way the
+// Bubble Tea runtime would, so progress only keeps arriving if every
+// progressMsg re-issues progressCmd.
+func TestUpdateResubscribes(t *testing.T) {
+	m := newTestModel(t)
+	const n = 5
+	for i := 1; i <= n; i++ {
+		m.ceartax.chProg <- progressMsg{module: "dirs", value: float64(i) / n}
+	}
+	msgs := make(chan tea.Msg, 16)
+	var run func(tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		go func() {
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, c := range batch {
+					run(c)
+				}
+				return
+			}
+			msgs <- msg
+		}()
+	}
+	run(m.progressCmd())
+	got := 0
+	for got < n {
+		select {
+		case msg := <-msgs:
+			if _, ok := msg.(progressMsg); !ok {
+				continue
+			}
+			got++
+			next, cmd := m.Update(msg)
+			m = next.(model)
+			run(cmd)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Update saw %d of %d progress messages", got, n)
+		}
+	}
+	if v := m.rates["dirs"].value; v != 1 {
+		t.Errorf("dirs at %v after %d messages, want 1", v, n)
+	}
+}
+
+func TestReportPaths(t *testing.T) {
+	const stamp = "20240501-120000"
+	for _, tc := range []struct {
+		name             string
+		c                *Ceartax
+		wantJSON, wantHT string
+	}{
+		{"json", &Ceartax{output: "x.json"}, "x.json", "x.html"},
+		{"no extension", &Ceartax{output: "x"}, "x", "x.html"},
+		{"html collides", &Ceartax{output: "x.html"}, "x.html", "x.html.html"},
+		{"timestamp", &Ceartax{output: "x.json", stamp: stamp}, "x_" + stamp + ".json", "x_" + stamp + ".html"},
+		{"timestamp html", &Ceartax{output: "x.html", stamp: stamp}, "x_" + stamp + ".html", "x.html_" + stamp + ".html"},
+		{"per-target-dir", &Ceartax{output: "x.json", perTargetDir: "out", target: "example.com"},
+			filepath.Join("out", "example.com", "x.json"), filepath.Join("out", "example.com", "x.html")},
+		{"per-target-dir html", &Ceartax{output: "x.html", perTargetDir: "out", target: "example.com"},
+			filepath.Join("out", "example.com", "x.html"), filepath.Join("out", "example.com", "x.html.html")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			j, h := tc.c.reportPaths()
+			if j != tc.wantJSON || h != tc.wantHT {
+				t.Errorf("reportPaths() = %q, %q, want %q, %q", j, h, tc.wantJSON, tc.wantHT)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	const text = "<html>hello, hello, hello</html>"
+	compress := func(wrap func(io.Writer) io.WriteCloser) func([]byte) []byte {
+		return func(in []byte) []byte {
+			var b bytes.Buffer
+			w := wrap(&b)
+			w.Write(in)
+			w.Close()
+			return b.Bytes()
+		}
+	}
+	gz := compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	zl := compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+	fl := compress(func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw })
+	br := compress(func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+	for _, tc := range []struct {
+		enc  string
+		body []byte
+		want string
+	}{
+		{"gzip", gz([]byte(text)), text},
+		{"x-gzip", gz([]byte(text)), text},
+		{"deflate", zl([]byte(text)), text},
+		{"deflate", fl([]byte(text)), text},
+		{"br", br([]byte(text)), text},
+		{"gzip, br", br(gz([]byte(text))), text},
+		{"GZIP", gz([]byte(text)), text},
+		{"gzip", nil, ""}, // 204 with the header set
+		{"br", nil, ""},
+		{"zstd", []byte(text), text}, // unknown: untouched
+	} {
+		got, err := io.ReadAll(decodeBody(tc.enc, io.NopCloser(bytes.NewReader(tc.body))))
+		if err != nil || string(got) != tc.want {
+			t.Errorf("%q (%d bytes): got %q, %v; want %q", tc.enc, len(tc.body), got, err, tc.want)
+		}
+	}
+}
+
+// -header and -cookie values reach the target, beat the UA rotation, and
+// never leave the target's scope.
+func TestHeadersScoped(t *testing.T) {
+	got := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+	}))
+	defer srv.Close()
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+	h := http.Header{}
+	h.Set("X-Team", "red")
+	h.Set("User-Agent", "our-agent/1.0")
+	h.Set("Cookie", "session=abc; lang=en")
+	c := newTestCeartax(t, Config{Target: "127.0.0.1", Headers: h})
+
+	for _, tc := range []struct {
+		host    string
+		inScope bool
+	}{
+		{"127.0.0.1", true},
+		{"localhost", false},
+	} {
+		u := fmt.Sprintf("http://%s:%d/", tc.host, port)
+		req, err := c.newRequest(context.Background(), "GET", u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		sent := <-got
+		for _, k := range []string{"X-Team", "User-Agent", "Cookie"} {
+			if match := sent.Get(k) == h.Get(k); match != tc.inScope {
+				t.Errorf("%s: %s = %q, want it sent: %v", tc.host, k, sent.Get(k), tc.inScope)
+			}
+		}
+	}
+}
+
+func TestDirRecurseDepth(t *testing.T) {
+	for _, tc := range []struct {
+		depth  int
+		nested bool
+	}{{1, false}, {2, true}} {
+		var nested atomic.Int64
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/admin":
+				http.Redirect(w, r, "/admin/", http.StatusMovedPermanently)
+			case r.URL.Path == "/admin/":
+			case strings.HasPrefix(r.URL.Path, "/admin/"):
+				nested.Add(1)
+				http.NotFound(w, r)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		c := newTestCeartax(t, Config{Target: srv.Listener.Addr().String(), DirRecurse: true, DirRecurseDepth: tc.depth})
+		err := c.Dirs(context.Background())
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nested.Load() > 0; got != tc.nested {
+			t.Errorf("-dir-recurse-depth %d: probed under /admin/: %v, want %v", tc.depth, got, tc.nested)
+		}
+	}
+}
+
+// After Ctrl+C the results are saved while cancelled modules may still
+// be recording; run with -race.
+func TestSaveWhileModulesWrite(t *testing.T) {
+	m := newTestModel(t)
+	m.ceartax.sinks = []Sink{jsonSink{path: filepath.Join(t.TempDir(), "r.json")}}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			m.ceartax.addSubdomain(fmt.Sprintf("h%d.example.com", i), []string{"192.0.2.1"})
+		}
+	}()
+	for end := time.Now().Add(200 * time.Millisecond); time.Now().Before(end); {
+		m.ready = false
+		m.finishNow()
+	}
+	close(stop)
+	wg.Wait()
+	if len(m.saveErrs) > 0 {
+		t.Errorf("save errors: %v", m.saveErrs)
+	}
+}
+
+// SIGTERM on the stay-up results screen must close the TUI: the scan is
+// over, so Interrupt alone has nothing left to stop.
+func TestSignalQuitsResultsScreen(t *testing.T) {
+	m := newTestModel(t)
+	m.ready, m.stay = true, true
+	_, cmd := m.Update(signalMsg{})
+	if cmd == nil {
+		t.Fatal("signalMsg on the results screen returned no command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("signalMsg on the results screen did not quit")
+	}
+}
+
+// A re-run saves again after the streams closed: the new report must
+// still carry the stream stats, and the re-run's finding, which the
+// closed stream dropped, must show as an output error.
+func TestRerunResaveKeepsStreams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	m := newTestModel(t)
+	path := filepath.Join(t.TempDir(), "r.json")
+	m.ceartax.sinks = []Sink{newHTTPStream(srv.URL, streamOpts{batch: 1}, http.DefaultTransport), jsonSink{path: path}}
+	m.stay = true
+	load := func() map[string]StreamStats {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r ReconResult
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(err)
+		}
+		return r.Streams
+	}
+
+	m.ceartax.sinks[0].(*streamSink).Emit(Finding{Type: "dir", Value: "/a"})
+	m.finishNow()
+	if got := load()["http"]; got.Delivered != 1 || got.Dropped != 0 {
+		t.Fatalf("first save: streams = %+v, want 1 delivered", got)
+	}
+
+	m.ready = false // as rerun does
+	m.ceartax.sinks[0].(*streamSink).Emit(Finding{Type: "dir", Value: "/b"})
+	m.finishNow()
+	if got := load()["http"]; got.Delivered != 1 || got.Dropped != 1 {
+		t.Errorf("re-save: streams = %+v, want 1 delivered and 1 dropped", got)
+	}
+	if len(m.saveErrs) != 1 || !strings.Contains(m.saveErrs[0], "1 findings dropped") {
+		t.Errorf("saveErrs = %q, want the one drop", m.saveErrs)
+	}
+	if v := m.View(); !strings.Contains(v, "re-run findings go to the report files only") {
+		t.Errorf("results screen does not say re-runs are not streamed:\n%s", v)
+	}
+}
+
+// A streamed wordlist that cannot be reopened fails Subdomains instead
+// of ending the round as if every word had been tried.
+func TestSubdomainsWordlistGone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	line := []byte("word\n")
+	if err := os.WriteFile(path, bytes.Repeat(line, subStreamMin/len(line)+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCeartax(t, Config{Target: "example.com", ListOnly: true})
+	if err := c.loadSubWords(path); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	if err := c.Subdomains(context.Background()); err == nil {
+		t.Error("Subdomains returned nil with the wordlist gone")
+	}
+}
+
+// -grep reads Dirs bodies within -page-timeout: a slow-drip page is cut
+// off and recorded, not waited on for the whole client timeout.
+func TestDirsGrepPageTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first bytes ")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	c := newTestCeartax(t, Config{
+		Target: srv.Listener.Addr().String(), Concurrency: 4,
+		Grep: regexp.MustCompile("first"), PageTimeout: 200 * time.Millisecond,
+	})
+	start := time.Now()
+	if err := c.Dirs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Dirs took %v with a 200ms page budget", d)
+	}
+	timeouts := 0
+	for _, pi := range c.result.PageIssues {
+		if pi.Issue == "timeout" {
+			timeouts++
+		}
+	}
+	if want := len(c.dirCandidates()); timeouts != want || len(c.result.Matches) != want {
+		t.Errorf("%d timeout issues and %d matches, want %d of each", timeouts, len(c.result.Matches), want)
+	}
+}